@@ -12,6 +12,16 @@ test-draw-rectangle: compile # create a rectangle with the given size and colour
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw rectangle --input=_test/test.jpg --point=650,400 --size=150,125 --colour=#FFFFFF --fill --radius=5 --output=dist/overlay_linux_amd64_v1/rounded-filled.png
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw rectangle --input=_test/test.jpg --point=650,500 --size=150,125 --colour=#FFFFFF --stroke=2 --radius=15 --output=dist/overlay_linux_amd64_v1/rounded-stroked.png
 
+.PHONY: test-draw-units
+test-draw-units: compile # draw using relative and physical units
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw canvas --size=10cm,5cm --dpi=300 --colour=#FFFFFF --output=dist/overlay_linux_amd64_v1/10x5cm.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw rectangle --input=_test/test.jpg --point=-25%,-1in --size=20%,50pt --colour=#FF0000 --fill --radius=2% --output=dist/overlay_linux_amd64_v1/units.png
+
+.PHONY: test-draw-anchor
+test-draw-anchor: compile # align elements to the edges of the image
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --anchor=south-east --offset=1cm,1cm --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/anchored-image.png --image=_test/apple.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=north --offset=0,5% --size=72 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/anchored-text.png --text="HALLO, WORLD!"
+
 .PHONY: test-draw-circle
 test-draw-circle: compile # create a circle with the given size and colour
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circle --input=_test/test.jpg --point=650,200 --colour=#FF0000 --fill --radius=100 --output=dist/overlay_linux_amd64_v1/filled.png
@@ -27,6 +37,56 @@ test-draw-elliptical-arc: compile # create an elliptical arc with the given size
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw elliptical-arc --input=_test/test.jpg --point=650,200 --colour=#FF0000 --fill --radius=100,50 --angle=0,90 --output=dist/overlay_linux_amd64_v1/elliptical-arc-90-degrees.png
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw elliptical-arc --input=_test/test.jpg --point=650,200 --colour=#00FF00 --stroke=10 --radius=100,50 --angle=0,270 --output=dist/overlay_linux_amd64_v1/elliptical-arc-270-degrees.png
 
+.PHONY: test-draw-line
+test-draw-line: compile # draw lines, polylines and polygons with caps, joins, dashes and arrowheads
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw line --input=_test/test.jpg --point=100,100 --point=600,300 --colour=#FF0000 --stroke=5 --arrow=both --output=dist/overlay_linux_amd64_v1/line.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw polyline --input=_test/test.jpg --point=100,500 --point=300,200 --point=500,400 --point=700,100 --colour=#FFFF00 --stroke=10 --cap=round --join=round --dash=30,20 --arrow=end --output=dist/overlay_linux_amd64_v1/polyline.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw polygon --input=_test/test.jpg --point=650,100 --point=800,350 --point=500,350 --colour=#00FF0080 --fill --output=dist/overlay_linux_amd64_v1/polygon-filled.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw polygon --input=_test/test.jpg --point=650,100 --point=800,350 --point=500,350 --colour=#00FF00 --stroke=10 --join=bevel --output=dist/overlay_linux_amd64_v1/polygon-stroked.png
+
+.PHONY: test-draw-sector
+test-draw-sector: compile # create circular and elliptical pie slices
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circular-sector --input=_test/test.jpg --point=650,200 --colour=#FF0000 --fill --radius=100 --angle=-90,45 --output=dist/overlay_linux_amd64_v1/circular-sector.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw elliptical-sector --input=_test/test.jpg --point=650,200 --colour=#00FF00 --stroke=10 --radius=100,50 --angle=30,330 --output=dist/overlay_linux_amd64_v1/elliptical-sector.png
+
+.PHONY: test-draw-ring
+test-draw-ring: compile # create complete rings and ring segments, e.g. for progress indicators
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circular-ring --input=_test/test.jpg --point=650,200 --colour=#FFFFFF80 --fill --radius=100 --inner-radius=80 --output=dist/overlay_linux_amd64_v1/circular-ring.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circular-ring --input=dist/overlay_linux_amd64_v1/circular-ring.png --point=650,200 --colour=#00FF00 --fill --radius=100 --inner-radius=80 --angle=-90,180 --output=dist/overlay_linux_amd64_v1/circular-ring-progress.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw elliptical-ring --input=_test/test.jpg --point=650,200 --colour=#FF0000 --stroke=5 --radius=150,75 --inner-radius=100,50 --angle=0,270 --output=dist/overlay_linux_amd64_v1/elliptical-ring.png
+
+.PHONY: test-draw-path
+test-draw-path: compile # draw shapes described by SVG path data, filled, stroked and transformed
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw path --input=_test/test.jpg --anchor=south-east --offset=2%,2% --scale=5 --fill --colour=#FF0000 --d="M12 21.35l-1.45-1.32C5.4 15.36 2 12.28 2 8.5 2 5.42 4.42 3 7.5 3c1.74 0 3.41.81 4.5 2.09C13.09 3.81 14.76 3 16.5 3 19.58 3 22 5.42 22 8.5c0 3.78-3.4 6.86-8.55 11.54L12 21.35z" --output=dist/overlay_linux_amd64_v1/path-filled.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw path --input=_test/test.jpg --translate=400,100 --rotate=15 --scale=2 --stroke=5 --join=round --colour=#FFFFFF --d="M0 0 h120 v80 h-80 l-40 40 z" --output=dist/overlay_linux_amd64_v1/path-callout.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw path --input=_test/test.jpg --translate=100,300 --stroke=8 --cap=round --colour=#FFFF00 --d="M10 80 A 45 45 0 0 0 125 80 Q 150 20 180 80 T 250 80" --output=dist/overlay_linux_amd64_v1/path-stroked.png
+
+.PHONY: test-draw-svg
+test-draw-svg: compile # overlay an SVG document, at its natural size and scaled to a given width
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --point=50,50 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/svg.png --image=_test/badge.svg
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --anchor=south-east --offset=2%,2% --size=50%,0 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/svg-scaled.png --image=_test/badge.svg
+
+.PHONY: test-draw-gradient
+test-draw-gradient: compile # paint shapes and the canvas with linear, radial and conic gradients
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw canvas --size=800,600 --colour="linear(0,0,0,100%,#1E3C72,#2A5298@60%,#FFFFFF)" --output=dist/overlay_linux_amd64_v1/gradient-canvas.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circle --input=_test/test.jpg --point=200,200 --radius=100 --fill --colour="radial(170,170,100,#FFFFFF,#CC0000@70%,#660000)" --output=dist/overlay_linux_amd64_v1/gradient-radial.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circle --input=_test/test.jpg --point=500,200 --radius=100 --fill --colour="conic(500,200,-90,#FF0000,#00FF00,#0000FF,#FF0000)" --output=dist/overlay_linux_amd64_v1/gradient-conic.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw polyline --input=_test/test.jpg --point=100,500 --point=400,400 --point=700,500 --stroke=10 --arrow=end --colour="linear(100,0,140,0,#000000,#FFFFFF80,reflect)" --output=dist/overlay_linux_amd64_v1/gradient-stroke.png
+
+.PHONY: test-draw-fill-image
+test-draw-fill-image: compile # fill shapes and text with images and built-in patterns
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw rectangle --input=_test/test.jpg --point=50,50 --size=300,200 --radius=20 --fill-image=diagonal --pattern-size=15 --pattern-width=3 --colour=#FFFFFF --output=dist/overlay_linux_amd64_v1/fill-hatch.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw circle --input=_test/test.jpg --point=500,300 --radius=150 --fill-image=_test/apple.png --fill-mode=stretch --output=dist/overlay_linux_amd64_v1/fill-stretch.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw ellipse --input=_test/test.jpg --point=500,300 --radius=300,150 --fill-image=_test/apple.png --fill-mode=repeat --output=dist/overlay_linux_amd64_v1/fill-repeat.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --input=_test/test.jpg --anchor=center --size=200 --font=_test/Economica/Economica-Bold.ttf --fill-image=_test/test.jpg --fill-mode=stretch --outline-colour=#FFFFFF --outline-width=4 --text="TEXTURE" --output=dist/overlay_linux_amd64_v1/fill-text.png
+
+.PHONY: test-draw-image-transform
+test-draw-image-transform: compile # scale, rotate and fade overlays, cropping and clipping them
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --anchor=south-east --offset=2%,2% --size=15%,15% --angle=-15 --opacity=0.5 --filter=lanczos --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/image-watermark.png --image=_test/apple.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --point=100,100 --size=300,150 --fit=fill --angle=30 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/image-fill.png --image=_test/apple.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --point=800,500 --scale=3 --filter=catmull-rom --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/image-clipped.png --image=_test/apple.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --anchor=center --size=60%,40% --fit=stretch --angle=10 --opacity=0.8 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/image-svg.png --image=_test/badge.svg
+
 .PHONY: test-draw-ellipse
 test-draw-ellipse: compile # create an ellipse with the given size and colour
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw ellipse --input=_test/test.jpg --point=650,200 --colour=#FF0000 --fill --radius=100,50 --output=dist/overlay_linux_amd64_v1/filled.png
@@ -36,6 +96,48 @@ test-draw-ellipse: compile # create an ellipse with the given size and colour
 test-draw-text: compile # overlay text on top of an image
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --point=650,100 --size=72 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/out.png --text="HALLO, WORLD!"
 
+.PHONY: test-draw-paragraph
+test-draw-paragraph: compile # write a wrapped, multi-line caption
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=south --offset=0,5% --width=80% --align=center --line-height=1.2 --size=48 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/paragraph.png --text="HALLO, WORLD!\nThis caption is long enough to be wrapped over several lines of text"
+
+.PHONY: test-draw-fit
+test-draw-fit: compile # fit a headline into a box
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --box=5%,5%,50%,30% --anchor=center --align=center --min-size=12 --max-size=144 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/fit.png --text="SUMMER SALE: everything must go this weekend"
+
+.PHONY: test-draw-effects
+test-draw-effects: compile # write text with outline, shadow and background plate
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=north --offset=0,5% --size=96 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --outline-colour=#000000 --outline-width=4 --shadow-colour=#00000080 --shadow-offset=6,6 --shadow-blur=4 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/effects.png --text="HALLO, WORLD!"
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=south --offset=0,5% --size=48 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --background-colour=#000000A0 --background-padding=10 --background-radius=8 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/plate.png --text="A caption on a plate"
+
+.PHONY: test-draw-rotated-text
+test-draw-rotated-text: compile # write rotated text and text along an arc
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=center --angle=-30 --size=144 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF80 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/watermark.png --text="WATERMARK"
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=center --arc-radius=200 --arc-angle=180,360 --align=center --size=64 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/arc.png --text="HALLO, WORLD!"
+
+.PHONY: test-draw-font-names
+test-draw-font-names: compile # write text with the built-in font and with a font given by family name
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=north --offset=0,5% --size=72 --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/default-font.png --text="HALLO, WORLD!"
+	@OVERLAY_LOG_LEVEL=d OVERLAY_FONT_PATH=_test dist/overlay_linux_amd64_v1/overlay draw text --anchor=north --offset=0,5% --size=72 --font="Economica:bold" --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/font-name.png --text="HALLO, WORLD!"
+
+FALLBACK_FONT ?= /usr/share/fonts/truetype/dejavu/DejaVuSans.ttf
+
+.PHONY: test-draw-scripts
+test-draw-scripts: compile # write bidirectional text with a fallback font (set FALLBACK_FONT to a font with Hebrew glyphs)
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=center --size=64 --font=_test/Economica/Economica-Regular.ttf --font=$(FALLBACK_FONT) --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/scripts.png --text="HALLO שלום 2026 WORLD"
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=south --offset=0,5% --direction=rtl --size=48 --font=$(FALLBACK_FONT) --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/rtl.png --text="שלום, WORLD!"
+
+.PHONY: test-draw-markup
+test-draw-markup: compile # write rich text with bold, italic, coloured and sized runs, wrapped to a box
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=center --size=56 --width=600 --align=center --font=_test/Economica/Economica-Regular.ttf --markup --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/markup.png --text="HALLO, <b>BOLD</b> <i>italic <b>both</b></i> <colour=#FF0000>red</colour> <size=96>big</size> and a &lt;tag&gt; wrapped across lines"
+
+.PHONY: test-draw-typography
+test-draw-typography: compile # write text with letter spacing, without kerning and with OpenType features
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=center --size=64 --font=_test/Economica/Economica-Regular.ttf --letter-spacing=8 --kerning=off --features=tnum,-liga --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/typography.png --text="AVATAR 1111"
+
+.PHONY: test-draw-template
+test-draw-template: compile # write text expanded as a template with the file name, size, EXIF fields, time and environment
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw text --anchor=south-east --offset=2%,2% --size=24 --colour=#FFFFFF --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/template.png --template --text='{{ .File }} ({{ .Width }}x{{ .Height }}), {{ exif "Model" "unknown camera" }}, {{ now.Format "2006-01-02" }}, {{ env "USER" "nobody" }}'
+
 .PHONY: test-draw-image
 test-draw-image: compile # overlay an image on top of an image
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw image --point=650,100 --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/out.jpg --image=_test/apple.png
@@ -63,6 +165,20 @@ test-info-size: compile # get the size of an image
 test-info-sample: compile # get the color of a pixel in an image
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay info sample --input=_test/test.jpg --point=485,323
 
+.PHONY: test-info-fonts
+test-info-fonts: compile # list the fonts available by family name
+	@OVERLAY_LOG_LEVEL=d OVERLAY_FONT_PATH=_test dist/overlay_linux_amd64_v1/overlay info fonts --verbose
+
+.PHONY: test-info-text
+test-info-text: compile # measure text as it would be drawn, wrapped to a box
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay info text --input=_test/test.jpg --font=_test/Economica/Economica-Regular.ttf --size=48 --width=300 --point=10,60 --text="HALLO, WORLD, wrapped over several lines"
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay info text --input=_test/test.jpg --font=_test/Economica/Economica-Regular.ttf --size=48 --anchor=center --json --text="HALLO"
+
+.PHONY: test-info-resolution
+test-info-resolution: compile # get the resolution of an image
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw canvas --size=640,480 --colour=#FF0000 --dpi=300 --output=dist/overlay_linux_amd64_v1/300dpi.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay info resolution --input=dist/overlay_linux_amd64_v1/300dpi.png
+
 .PHONY: test-transform-crop
 test-transform-crop: compile # crop an image
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform crop --input=_test/test.jpg --rectangle=0,0,640,480 --output=dist/overlay_linux_amd64_v1/cropped.png
@@ -83,3 +199,22 @@ test-transform-flipv: compile # flip an image vertically
 test-transform-zoom: compile # zoom the image
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform zoom --input=_test/test.jpg --factor=2.0 --pivot=10,10 --output=dist/overlay_linux_amd64_v1/zoomed-in.png
 	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform zoom --input=_test/test.jpg --factor=0.5 --pivot=10,10 --output=dist/overlay_linux_amd64_v1/zoomed-out.png
+
+.PHONY: test-draw-scene
+test-draw-scene: compile # render a whole composition described by a YAML document
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay draw scene --scene=_test/scene.yaml --output=dist/overlay_linux_amd64_v1/scene.png
+
+.PHONY: test-chain
+test-chain: compile # overlay images and text on top of an image with a single decode and a single encode
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay chain --input=_test/test.jpg --output=dist/overlay_linux_amd64_v1/chain.jpg \
+		draw image --point=460,25 --image=_test/apple.png + \
+		draw text --point=600,100 --size=72 --font=_test/Economica/Economica-Regular.ttf --colour=#FFFFFF --text="HALLO, WORLD..." + \
+		draw text --point=700,160 --size=48 --font=_test/Economica/Economica-Regular.ttf --colour=#00FF0033 --text="... from me!"
+
+.PHONY: test-encoding
+test-encoding: compile # encode images with different quality and compression options
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform fliph --input=_test/test.jpg --jpeg-quality=80 --output=dist/overlay_linux_amd64_v1/quality-80.jpg
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform fliph --input=_test/test.jpg --png-compression=best --output=dist/overlay_linux_amd64_v1/best-compression.png
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform fliph --input=_test/test.jpg --gif-colours=16 --gif-quantizer=median-cut --output=dist/overlay_linux_amd64_v1/median-cut-16.gif
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform fliph --input=_test/test.jpg --gif-quantizer=websafe --gif-dither=none --output=dist/overlay_linux_amd64_v1/websafe-no-dither.gif
+	@OVERLAY_LOG_LEVEL=d dist/overlay_linux_amd64_v1/overlay transform fliph --input=_test/test.jpg --tiff-compression=deflate --output=dist/overlay_linux_amd64_v1/deflate.tiff
@@ -1,55 +1,108 @@
 package base
 
 import (
+	"bytes"
 	"fmt"
 	"image"
-	"image/gif"
-	"image/jpeg"
+	"image/draw"
 	"image/png"
 	"io"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"strings"
 
+	"github.com/dihedron/overlay/pipeline"
 	"github.com/jessevdk/go-flags"
-	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
 )
 
 // InputCommand is the base command for commands that take an input file.
 type InputCommand struct {
 	// Input is the name of the input file.
 	Input flags.Filename `short:"i" long:"input" description:"The name of the input file or - for STDIN" optional:"true" default:"-"`
+	// source describes the input image.
+	source Source
 }
 
 // ReadInput reads the input image from the input stream.
 func (cmd *InputCommand) ReadInput() (image.Image, error) {
+	data, base, err := readImage(string(cmd.Input))
+	if err != nil {
+		slog.Error("error reading base image", "name", cmd.Input, "error", err)
+		return nil, err
+	}
+	cmd.source = Source{EXIF: pipeline.EXIF(data)}
+	if dpi, ok := pipeline.Resolution(data); ok {
+		slog.Debug("input image resolution found", "name", cmd.Input, "dpi", dpi)
+		cmd.source.Resolution = dpi
+	}
+	if cmd.Input != "-" {
+		cmd.source.Path = string(cmd.Input)
+	}
+	slog.Debug("input image metadata read", "name", cmd.Input, "exif", len(cmd.source.EXIF))
+	return base, nil
+}
+
+// readImage reads and decodes the image in the given file, or on STDIN if
+// the name is -; the raw data is returned as well, so that the metadata of
+// the image can be inspected.
+func readImage(name string) ([]byte, image.Image, error) {
 	// open the input stream
 	var input io.Reader
 
-	if cmd.Input == "-" {
+	if name == "-" {
 		// read from standard input
 		slog.Debug("reading input from STDIN")
 		input = os.Stdin
 	} else {
-		// open the underlay image file
-		slog.Debug("reading input from file", "name", cmd.Input)
-		var err error
-		if input, err = os.Open(string(cmd.Input)); err != nil {
-			slog.Error("error opening input file", "name", cmd.Input, "error", err)
-			return nil, err
+		// open the image file
+		slog.Debug("reading input from file", "name", name)
+		f, err := os.Open(name)
+		if err != nil {
+			slog.Error("error opening input file", "name", name, "error", err)
+			return nil, nil, err
 		}
-		defer input.(io.ReadCloser).Close()
+		defer f.Close()
+		input = f
 	}
 
-	// decode the base image
-	base, _, err := image.Decode(input)
+	// read the whole image, so that its metadata can be inspected
+	data, err := io.ReadAll(input)
 	if err != nil {
-		slog.Error("error decoding input data for base image", "name", cmd.Input, "error", err)
-		return nil, err
+		slog.Error("error reading input data", "name", name, "error", err)
+		return nil, nil, err
 	}
 
-	return base, nil
+	// decode the image
+	img, _, err := pipeline.Decode(bytes.NewReader(data))
+	if err != nil {
+		slog.Error("error decoding input data", "name", name, "error", err)
+		return nil, nil, err
+	}
+	return data, img, nil
+}
+
+// NeedsInput returns whether the command needs an input image, which is
+// always the case unless overridden.
+func (cmd *InputCommand) NeedsInput() bool {
+	return true
+}
+
+// InputResolution returns the resolution of the input image, in DPI, or zero
+// if the input image has not been read yet or carries no resolution information.
+func (cmd *InputCommand) InputResolution() float64 {
+	return cmd.source.Resolution
+}
+
+// InputSource returns the description of the input image, which is empty if
+// the input image has not been read yet.
+func (cmd *InputCommand) InputSource() Source {
+	return cmd.source
+}
+
+// InheritSource sets the description of the input image, when the image is
+// read by another command (e.g. a chain).
+func (cmd *InputCommand) InheritSource(source Source) {
+	cmd.source = source
 }
 
 // OutputCommand is the base command for commands that produce an output file.
@@ -57,9 +110,106 @@ type OutputCommand struct {
 	// Output is the name of the output file.
 	Output flags.Filename `short:"o" long:"output" description:"The name of the output file or - for STDOUT" optional:"true" default:"-"`
 	// Format is the output format, if an output filename is not specified; it is used for chaining.
-	Format string `short:"x" long:"format" description:"Format of the output image" optional:"true" choice:"jpeg" choice:"jpg" choice:"png" choice:"gif" choice:"bmp" default:"png"`
-	// DPI is the image resolution in Dots Per Inch.
-	DPI float64 `short:"d" long:"dpi" description:"The image resolution in DPI - Dots Per Inch" optional:"true" default:"72"`
+	Format string `short:"x" long:"format" description:"Format of the output image" optional:"true" choice:"jpeg" choice:"jpg" choice:"png" choice:"gif" choice:"bmp" choice:"tiff" choice:"tif" default:"png"`
+	// DPI is the image resolution in Dots Per Inch; if not specified, the
+	// resolution of the input image is used.
+	DPI float64 `short:"d" long:"dpi" description:"The image resolution in DPI - Dots Per Inch; if not specified, that of the input image is kept, or 72 is used" optional:"true"`
+	// EncodingOptions holds the format-specific encoder options.
+	EncodingOptions
+}
+
+// EncodingOptions holds the options that control how the output image is
+// encoded; each of them only applies to one output format.
+type EncodingOptions struct {
+	// Quality is the JPEG quality.
+	Quality int `short:"q" long:"jpeg-quality" description:"The quality of JPEG images, from 1 to 100" optional:"true" default:"75"`
+	// Compression is the PNG compression level.
+	Compression string `long:"png-compression" description:"The compression level of PNG images" optional:"true" choice:"none" choice:"fast" choice:"default" choice:"best" default:"default"`
+	// Colours is the size of the GIF palette.
+	Colours int `long:"gif-colours" description:"The maximum number of colours in the palette of GIF images, from 1 to 256" optional:"true" default:"256"`
+	// Quantizer is the algorithm used to build the GIF palette.
+	Quantizer string `long:"gif-quantizer" description:"The algorithm used to build the palette of GIF images" optional:"true" choice:"plan9" choice:"websafe" choice:"median-cut" default:"plan9"`
+	// Dither is the algorithm used to map the image colours to the GIF palette.
+	Dither string `long:"gif-dither" description:"The dithering applied when mapping colours to the palette of GIF images" optional:"true" choice:"floyd-steinberg" choice:"none" default:"floyd-steinberg"`
+	// TIFFCompression is the TIFF compression type.
+	TIFFCompression string `long:"tiff-compression" description:"The compression of TIFF images" optional:"true" choice:"none" choice:"deflate" default:"deflate"`
+}
+
+// Encoder returns an encoder for the given format, configured according
+// to the encoding options; only the options that apply to the format are
+// validated.
+func (opts *EncodingOptions) Encoder(format string) (*pipeline.Encoder, error) {
+	encoder := &pipeline.Encoder{
+		Format:  format,
+		Quality: opts.Quality,
+		Colours: opts.Colours,
+	}
+
+	switch format {
+	case "jpeg", "jpg":
+		if opts.Quality < 1 || opts.Quality > 100 {
+			return nil, fmt.Errorf("invalid JPEG quality: %d", opts.Quality)
+		}
+	case "gif":
+		if opts.Colours < 1 || opts.Colours > 256 {
+			return nil, fmt.Errorf("invalid number of GIF colours: %d", opts.Colours)
+		}
+	}
+
+	switch opts.Compression {
+	case "none":
+		encoder.PNGCompression = png.NoCompression
+	case "fast":
+		encoder.PNGCompression = png.BestSpeed
+	case "best":
+		encoder.PNGCompression = png.BestCompression
+	default:
+		encoder.PNGCompression = png.DefaultCompression
+	}
+
+	switch opts.Quantizer {
+	case "websafe":
+		encoder.Quantizer = pipeline.WebSafe{}
+	case "median-cut":
+		encoder.Quantizer = pipeline.MedianCut{}
+	}
+
+	switch opts.Dither {
+	case "none":
+		encoder.Drawer = draw.Src
+	default:
+		encoder.Drawer = draw.FloydSteinberg
+	}
+
+	switch opts.TIFFCompression {
+	case "none":
+		encoder.TIFFCompression = tiff.Uncompressed
+	default:
+		encoder.TIFFCompression = tiff.Deflate
+	}
+
+	return encoder, nil
+}
+
+// DefaultDPI is the resolution used when neither the --dpi flag nor the
+// input image specify one.
+const DefaultDPI = 72
+
+// InheritResolution sets the output resolution to the given value, unless it
+// was explicitly specified on the command line.
+func (cmd *OutputCommand) InheritResolution(dpi float64) {
+	if cmd.DPI <= 0 && dpi > 0 {
+		slog.Debug("inheriting resolution", "dpi", dpi)
+		cmd.DPI = dpi
+	}
+}
+
+// OutputResolution returns the resolution of the output image, in DPI.
+func (cmd *OutputCommand) OutputResolution() float64 {
+	if cmd.DPI > 0 {
+		return cmd.DPI
+	}
+	return DefaultDPI
 }
 
 // OutputStream returns an io.Writer for the output file.
@@ -70,26 +220,27 @@ func (cmd *OutputCommand) WriteOutput(img image.Image) error {
 		err    error
 	)
 
+	// check the output format
+	if cmd.Output != "-" {
+		if cmd.Format, err = pipeline.FormatFromPath(string(cmd.Output)); err != nil {
+			slog.Error("unsupported output image type", "name", cmd.Output)
+			return err
+		}
+	}
+
+	// prepare the encoder
+	encoder, err := cmd.Encoder(cmd.Format)
+	if err != nil {
+		slog.Error("invalid encoding options", "name", cmd.Output, "error", err, "format", cmd.Format)
+		return err
+	}
+	encoder.DPI = cmd.OutputResolution()
+
 	if cmd.Output == "-" {
 		// writing image to standard output
 		slog.Debug("writing image to STDOUT", "format", cmd.Format)
 		output = os.Stdout
 	} else {
-		// check the output format
-		switch strings.ToLower(filepath.Ext(string(cmd.Output))) {
-		case ".jpg", ".jpeg":
-			cmd.Format = "jpg"
-		case ".png":
-			cmd.Format = "png"
-		case ".gif":
-			cmd.Format = "gif"
-		case ".bmp":
-			cmd.Format = "bmp"
-		default:
-			err = fmt.Errorf("unsupported output file type: %s", filepath.Ext(string(cmd.Output)))
-			slog.Error("unsupported output image type", "name", cmd.Output)
-			return err
-		}
 		slog.Debug("writing output to file", "name", cmd.Output, "format", cmd.Format)
 
 		// open the output file
@@ -102,35 +253,9 @@ func (cmd *OutputCommand) WriteOutput(img image.Image) error {
 
 	// encode the output image
 	slog.Debug("encoding output image", "name", cmd.Output, "format", cmd.Format)
-	switch cmd.Format {
-	case "jpg", "jpeg":
-		slog.Debug("encoding output file as JPEG", "name", cmd.Output)
-		if err = jpeg.Encode(output, img, nil); err != nil {
-			slog.Error("error encoding output file", "name", cmd.Output, "error", err, "format", cmd.Format)
-			return err
-		}
-	case "png":
-		slog.Debug("encoding output file as PNG", "name", cmd.Output)
-		if err = png.Encode(output, img); err != nil {
-			slog.Error("error encoding output file", "name", cmd.Output, "error", err, "format", cmd.Format)
-			return err
-		}
-	case "gif":
-		slog.Debug("encoding output file as GIF", "name", cmd.Output)
-		if err = gif.Encode(output, img, nil); err != nil {
-			slog.Error("error encoding output file", "name", cmd.Output, "error", err, "format", cmd.Format)
-			return err
-		}
-	case "bmp":
-		slog.Debug("encoding output file as BMP", "name", cmd.Output)
-		if err = bmp.Encode(output, img); err != nil {
-			slog.Error("error encoding output file", "name", cmd.Output, "error", err, "format", cmd.Format)
-			return err
-		}
-	default:
-		fmt.Fprintf(os.Stderr, "Unsupported output format: %s\n", cmd.Format)
-		slog.Error("unsupported output format", "name", cmd.Output, "format", cmd.Format)
-		return fmt.Errorf("unsupported output format: %s", cmd.Format)
+	if err = encoder.Encode(output, img); err != nil {
+		slog.Error("error encoding output file", "name", cmd.Output, "error", err, "format", cmd.Format)
+		return err
 	}
 
 	slog.Debug("output image written")
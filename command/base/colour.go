@@ -1,10 +1,16 @@
 package base
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
 	"log/slog"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Colour is a colour in the format #RGB, #RGBA, #RRGGBB, or #RRGGBBAA.
@@ -123,3 +129,223 @@ func (c *Colour) UnmarshalFlag(value string) error {
 func (c Colour) MarshalFlag() (string, error) {
 	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A), nil
 }
+
+// Optional returns the colour, or nil if it is fully transparent, e.g. because
+// it was not specified on the command line.
+func (c Colour) Optional() color.Color {
+	if c.A == 0 {
+		return nil
+	}
+	return c
+}
+
+// RGBA implements the color.Color interface; the colour components are stored
+// as they are given on the command line (i.e. not alpha-premultiplied), so they
+// are premultiplied here before being returned.
+func (c Colour) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
+
+// Paint is what shapes are filled or stroked with: either a colour, in any of
+// the formats of Colour, or a gradient given as one of
+//
+//	linear(x1,y1,x2,y2,stops...[,spread])
+//	radial(cx,cy,r[,fx,fy],stops...[,spread])
+//	conic(cx,cy[,angle],stops...)
+//
+// where the coordinates are points in the image (with optional units, as
+// --point), r is a radius and angle is where a conic gradient starts, in
+// degrees clockwise from the positive x axis; each stop is a colour with an
+// optional offset from 0 to 1 (or 0% to 100%) along the gradient, as in
+// #FF0000@0.5, and stops without an offset are spread evenly between their
+// neighbours. Unlike in plain colours, and as in markup and SVG documents,
+// the short formats #RGB and #RGBA of stops double each digit, so that #F00
+// is the same as #FF0000. The optional spread (pad, repeat or reflect) is how the
+// gradient continues past its ends. For instance,
+// linear(0,0,0,100%,#F00@0,#00F@1) fades from red at the top of the image to
+// blue at the bottom.
+type Paint struct {
+	// Colour is the colour of the paint or, for gradients, that of its first
+	// stop.
+	Colour
+	// gradient is the gradient, if the paint is not a plain colour.
+	gradient *gradient
+}
+
+// gradient is a gradient as given on the command line, whose coordinates
+// are resolved against the image it is painted on.
+type gradient struct {
+	spec   string
+	kind   pipeline.GradientKind
+	points []Point
+	radius Length
+	angle  float64
+	stops  []pipeline.GradientStop
+	spread pipeline.Spread
+}
+
+// gradientSpec matches a gradient specification, e.g. linear(...).
+var gradientSpec = regexp.MustCompile(`^\s*(linear|radial|conic)\s*\((.*)\)\s*$`)
+
+// UnmarshalFlag parses a string representation of a paint, either a colour or
+// a gradient.
+func (p *Paint) UnmarshalFlag(value string) error {
+	match := gradientSpec.FindStringSubmatch(value)
+	if match == nil {
+		p.gradient = nil
+		return p.Colour.UnmarshalFlag(value)
+	}
+
+	g := &gradient{spec: strings.TrimSpace(value), kind: pipeline.GradientKind(match[1]), spread: pipeline.SpreadPad}
+	args := strings.Split(match[2], ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	if n := len(args); n > 0 {
+		switch spread := pipeline.Spread(args[n-1]); spread {
+		case pipeline.SpreadPad, pipeline.SpreadRepeat, pipeline.SpreadReflect:
+			g.spread, args = spread, args[:n-1]
+		}
+	}
+
+	// the geometry comes before the first stop
+	first := slices.IndexFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "#") })
+	if first < 0 {
+		return fmt.Errorf("invalid gradient %q: no colour stops", value)
+	}
+	lengths := make([]Length, first)
+	for i, arg := range args[:first] {
+		if err := lengths[i].UnmarshalFlag(arg); err != nil {
+			return fmt.Errorf("invalid gradient %q: %w", value, err)
+		}
+	}
+	switch {
+	case g.kind == pipeline.LinearGradient && len(lengths) == 4:
+		g.points = []Point{{X: lengths[0], Y: lengths[1]}, {X: lengths[2], Y: lengths[3]}}
+	case g.kind == pipeline.RadialGradient && (len(lengths) == 3 || len(lengths) == 5):
+		g.points = []Point{{X: lengths[0], Y: lengths[1]}}
+		g.radius = lengths[2]
+		if len(lengths) == 5 {
+			g.points = append(g.points, Point{X: lengths[3], Y: lengths[4]})
+		}
+	case g.kind == pipeline.ConicGradient && (len(lengths) == 2 || len(lengths) == 3):
+		g.points = []Point{{X: lengths[0], Y: lengths[1]}}
+		if len(lengths) == 3 {
+			if lengths[2].Unit != Pixels {
+				return fmt.Errorf("invalid gradient %q: the angle must be in degrees", value)
+			}
+			g.angle = lengths[2].Value
+		}
+	default:
+		return fmt.Errorf("invalid gradient %q: wrong number of coordinates for a %s gradient", value, g.kind)
+	}
+
+	stops, err := parseStops(args[first:])
+	if err != nil {
+		return fmt.Errorf("invalid gradient %q: %w", value, err)
+	}
+	g.stops = stops
+	p.gradient = g
+	p.Colour = Colour(color.NRGBAModel.Convert(stops[0].Colour).(color.NRGBA))
+	slog.Debug("parsed gradient", "kind", g.kind, "points", len(g.points), "stops", len(g.stops), "spread", g.spread)
+	return nil
+}
+
+// parseStops parses the colour stops of a gradient, each a colour with an
+// optional offset; the offsets missing are spread evenly between those of
+// the neighbouring stops, the first stop defaulting to 0 and the last to 1.
+func parseStops(args []string) ([]pipeline.GradientStop, error) {
+	if len(args) < 2 {
+		return nil, errors.New("at least two colour stops are needed")
+	}
+	stops := make([]pipeline.GradientStop, len(args))
+	given := make([]bool, len(args))
+	for i, arg := range args {
+		colour, offset, ok := strings.Cut(arg, "@")
+		parsed, err := pipeline.ParseColour(strings.TrimSpace(colour))
+		if err != nil {
+			return nil, err
+		}
+		stops[i].Colour = parsed
+		if ok {
+			offset = strings.TrimSpace(offset)
+			scale := 1.0
+			if strings.HasSuffix(offset, "%") {
+				offset, scale = strings.TrimSuffix(offset, "%"), 0.01
+			}
+			v, err := strconv.ParseFloat(offset, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset of colour stop %q: %w", arg, err)
+			}
+			stops[i].Offset, given[i] = min(max(v*scale, 0), 1), true
+		}
+	}
+	if !given[0] {
+		stops[0].Offset, given[0] = 0, true
+	}
+	if last := len(stops) - 1; !given[last] {
+		stops[last].Offset, given[last] = 1, true
+	}
+	for i := 1; i < len(stops); i++ {
+		// offsets never go back
+		if given[i] {
+			stops[i].Offset = max(stops[i].Offset, stops[i-1].Offset)
+			continue
+		}
+		next := i + 1
+		for !given[next] {
+			next++
+		}
+		end := max(stops[next].Offset, stops[i-1].Offset)
+		stops[i].Offset = stops[i-1].Offset + (end-stops[i-1].Offset)/float64(next-i+1)
+	}
+	return stops, nil
+}
+
+// MarshalFlag returns the string representation of a paint.
+func (p Paint) MarshalFlag() (string, error) {
+	if p.gradient != nil {
+		return p.gradient.spec, nil
+	}
+	return p.Colour.MarshalFlag()
+}
+
+// String returns the string representation of a paint.
+func (p Paint) String() string {
+	s, _ := p.MarshalFlag()
+	return s
+}
+
+// IsGradient returns whether the paint is a gradient rather than a colour.
+func (p Paint) IsGradient() bool {
+	return p.gradient != nil
+}
+
+// Gradient returns the gradient of the paint, with the coordinates resolved
+// within an image of the given size and resolution, in DPI; percentages of
+// the radius refer to the shorter side of the image. It returns nil if the
+// paint is a plain colour.
+func (p Paint) Gradient(width, height int, dpi float64) *pipeline.Gradient {
+	if p.gradient == nil {
+		return nil
+	}
+	g := &pipeline.Gradient{
+		Kind:   p.gradient.kind,
+		Stops:  p.gradient.stops,
+		Spread: p.gradient.spread,
+		Angle:  p.gradient.angle,
+	}
+	switch g.Kind {
+	case pipeline.LinearGradient:
+		g.X1, g.Y1 = p.gradient.points[0].Resolve(width, height, dpi)
+		g.X2, g.Y2 = p.gradient.points[1].Resolve(width, height, dpi)
+	default:
+		g.CX, g.CY = p.gradient.points[0].Resolve(width, height, dpi)
+		g.FX, g.FY = g.CX, g.CY
+		if len(p.gradient.points) > 1 {
+			g.FX, g.FY = p.gradient.points[1].Resolve(width, height, dpi)
+		}
+		g.Radius = p.gradient.radius.ResolveIn(width, height, dpi)
+	}
+	return g
+}
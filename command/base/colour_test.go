@@ -0,0 +1,47 @@
+package base
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/dihedron/overlay/pipeline"
+)
+
+func TestGradientShortStops(t *testing.T) {
+	var paint Paint
+	if err := paint.UnmarshalFlag("linear(0,0,0,100,#F00@0,#00F@1)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gradient := paint.Gradient(10, 100, DefaultDPI)
+	if gradient == nil {
+		t.Fatal("expected a gradient")
+	}
+
+	canvas := pipeline.NewCanvas(10, 100)
+	defer canvas.Close()
+	if _, err := canvas.Apply(pipeline.GradientBackdrop(*gradient)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img := canvas.Image()
+
+	tests := []struct {
+		y    int
+		want color.NRGBA
+	}{
+		{0, color.NRGBA{R: 255, A: 255}},
+		{99, color.NRGBA{B: 255, A: 255}},
+	}
+	for _, test := range tests {
+		got := color.NRGBAModel.Convert(img.At(5, test.y)).(color.NRGBA)
+		if diff(got.R, test.want.R) > 8 || diff(got.G, test.want.G) > 8 || diff(got.B, test.want.B) > 8 || got.A != test.want.A {
+			t.Errorf("pixel at row %d: got %v, want %v", test.y, got, test.want)
+		}
+	}
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
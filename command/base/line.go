@@ -0,0 +1,24 @@
+package base
+
+import (
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// LineStyle holds the options that determine how the outline of a shape is
+// stroked, beyond its width.
+type LineStyle struct {
+	// Cap is the shape of the ends of open lines.
+	Cap string `long:"cap" description:"The shape of the ends of the lines" optional:"true" choice:"butt" choice:"round" choice:"square" default:"butt"`
+	// Join is the shape of the corners between segments.
+	Join string `long:"join" description:"The shape of the corners where segments meet" optional:"true" choice:"miter" choice:"round" choice:"bevel" default:"miter"`
+	// Dash is the dash pattern of the lines.
+	Dash Lengths `long:"dash" description:"The dash pattern of the lines, as a list of alternating dash and gap lengths (e.g. 10,5); by default, lines are solid" optional:"true"`
+}
+
+// Configure sets the line options into the given style, resolving the dash
+// pattern within an image of the given size and resolution, in DPI.
+func (l *LineStyle) Configure(style *pipeline.Style, width, height int, dpi float64) {
+	style.Cap = pipeline.LineCap(l.Cap)
+	style.Join = pipeline.LineJoin(l.Join)
+	style.Dash = l.Dash.ResolveIn(width, height, dpi)
+}
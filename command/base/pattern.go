@@ -0,0 +1,73 @@
+package base
+
+import (
+	"image/color"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/dihedron/overlay/pipeline"
+	"github.com/jessevdk/go-flags"
+)
+
+// FillPattern holds the options that fill an element (e.g. a shape or some
+// text) with an image, or with one of the built-in patterns, instead of its
+// colour.
+type FillPattern struct {
+	// FillImage is the image, or the name of the built-in pattern, the element is filled with.
+	FillImage flags.Filename `long:"fill-image" description:"The image the element is filled with instead of its colour, or one of the built-in patterns horizontal, vertical, diagonal, back-diagonal, cross, diagonal-cross, checkerboard or dots, drawn in the colour, unless a file with that name exists" optional:"true"`
+	// FillMode is how the image is laid out within the bounding box of the element.
+	FillMode string `long:"fill-mode" description:"How the fill image is laid out within the bounding box of the element; built-in patterns are always repeated" optional:"true" choice:"repeat" choice:"no-repeat" choice:"stretch" default:"repeat"`
+	// PatternSize is the size of the tiles of the built-in patterns.
+	PatternSize Length `long:"pattern-size" description:"The size of the tiles of the built-in patterns, i.e. the distance between their lines" optional:"true" default:"10"`
+	// PatternWidth is the width of the lines of the built-in patterns.
+	PatternWidth Length `long:"pattern-width" description:"The width of the lines of the built-in patterns" optional:"true" default:"2"`
+}
+
+// IsPatterned returns whether the element is filled with an image or a
+// pattern.
+func (p *FillPattern) IsPatterned() bool {
+	return p.FillImage != ""
+}
+
+// Pattern returns the pattern filling an element of the given colour, whose
+// bounding box has the top-left corner at (x, y) and the given size, within
+// an image of the given size and resolution, in DPI; it returns nil if the
+// element is not filled with an image or a pattern.
+func (p *FillPattern) Pattern(x, y, width, height float64, colour color.Color, canvasWidth, canvasHeight int, dpi float64) (*pipeline.Pattern, error) {
+	if !p.IsPatterned() {
+		return nil, nil
+	}
+	pattern := &pipeline.Pattern{
+		Mode:   pipeline.PatternMode(p.FillMode),
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+	}
+
+	// a file named as a built-in pattern takes precedence over it
+	hatch := pipeline.Hatch(p.FillImage)
+	if _, err := os.Stat(string(p.FillImage)); err != nil && slices.Contains(pipeline.Hatches, hatch) {
+		size := int(math.Round(p.PatternSize.ResolveIn(canvasWidth, canvasHeight, dpi)))
+		lineWidth := p.PatternWidth.ResolveIn(canvasWidth, canvasHeight, dpi)
+		slog.Debug("filling with built-in pattern", "pattern", hatch, "size", size, "width", lineWidth)
+		tile, err := hatch.Tile(size, lineWidth, colour)
+		if err != nil {
+			slog.Error("error drawing pattern tile", "pattern", hatch, "error", err)
+			return nil, err
+		}
+		pattern.Image, pattern.Mode = tile, pipeline.PatternRepeat
+		return pattern, nil
+	}
+
+	slog.Debug("reading fill image from file", "name", p.FillImage, "mode", p.FillMode)
+	_, img, err := readImage(string(p.FillImage))
+	if err != nil {
+		slog.Error("error reading fill image", "name", p.FillImage, "error", err)
+		return nil, err
+	}
+	pattern.Image = img
+	return pattern, nil
+}
@@ -0,0 +1,28 @@
+package base
+
+import (
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Placement holds the options that align an element (e.g. a shape, some text
+// or an image) to the image it is painted on, as an alternative to giving the
+// coordinates of a point.
+type Placement struct {
+	// Anchor is the point of the underlay image the element is aligned to.
+	Anchor string `long:"anchor" description:"The point of the underlay image the bounding box of the element is aligned to; if specified, the point is ignored" optional:"true" choice:"north-west" choice:"north" choice:"north-east" choice:"west" choice:"center" choice:"east" choice:"south-west" choice:"south" choice:"south-east"`
+	// Offset is the distance of the element from the anchor.
+	Offset Size `long:"offset" description:"The offset of the element from the anchor, as an (x,y) pair; it moves the element away from the edges it is aligned to" optional:"true"`
+}
+
+// IsAnchored returns whether the element is positioned through an anchor.
+func (p *Placement) IsAnchored() bool {
+	return p.Anchor != ""
+}
+
+// Align returns the top-left corner of an element with a bounding box of the
+// given size, aligned to the anchor of an image of the given size and
+// resolution, in DPI.
+func (p *Placement) Align(width, height float64, canvasWidth, canvasHeight int, dpi float64) (float64, float64, error) {
+	dx, dy := p.Offset.Resolve(canvasWidth, canvasHeight, dpi)
+	return pipeline.Align(pipeline.Anchor(p.Anchor), width, height, float64(canvasWidth), float64(canvasHeight), dx, dy)
+}
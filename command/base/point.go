@@ -3,87 +3,292 @@ package base
 import (
 	"errors"
 	"fmt"
+	"image"
+	"math"
 	"strconv"
 	"strings"
 )
 
-// Size is a 2D coordinate.
-type Size struct {
-	X, Y int
+// Unit is the unit of measure of a length.
+type Unit string
+
+const (
+	// Pixels is the default unit, used when none is given.
+	Pixels Unit = ""
+	// Percent expresses a length relative to the size of the image.
+	Percent Unit = "%"
+	// Inches is a physical unit, converted to pixels through the resolution.
+	Inches Unit = "in"
+	// Centimeters is a physical unit, converted to pixels through the resolution.
+	Centimeters Unit = "cm"
+	// Millimeters is a physical unit, converted to pixels through the resolution.
+	Millimeters Unit = "mm"
+	// Points is a physical unit (1/72 of an inch), converted to pixels through the resolution.
+	Points Unit = "pt"
+)
+
+// Length is a linear measure; unless it is in pixels, it must be resolved
+// against the size and the resolution of the image it applies to.
+type Length struct {
+	Value float64
+	Unit  Unit
 }
 
-// UnmarshalFlag parses a string representation of a size in the format "x,y".
-func (p *Size) UnmarshalFlag(value string) error {
-	parts := strings.Split(value, ",")
+// UnmarshalFlag parses a string representation of a length, i.e. a number
+// optionally followed by one of the px, %, in, cm, mm or pt units.
+func (l *Length) UnmarshalFlag(value string) error {
+	value = strings.TrimSpace(value)
+	unit := Pixels
+	for _, u := range []Unit{"px", Percent, Inches, Centimeters, Millimeters, Points} {
+		if strings.HasSuffix(value, string(u)) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, string(u)))
+			if u != "px" {
+				unit = u
+			}
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid length: %w", err)
+	}
+	l.Value = v
+	l.Unit = unit
+	return nil
+}
 
-	if len(parts) != 2 {
-		return errors.New("invalid format: expected two numbers separated by a ,")
+// MarshalFlag returns the string representation of a length.
+func (l Length) MarshalFlag() (string, error) {
+	return l.String(), nil
+}
+
+// String returns the string representation of a length.
+func (l Length) String() string {
+	return fmt.Sprintf("%g%s", l.Value, l.Unit)
+}
+
+// IsRelative returns whether the length is relative to the size of the image.
+func (l Length) IsRelative() bool {
+	return l.Unit == Percent
+}
+
+// Resolve returns the length in pixels; percentages refer to the given extent
+// and physical units are converted through the given resolution, in DPI.
+func (l Length) Resolve(extent float64, dpi float64) float64 {
+	switch l.Unit {
+	case Percent:
+		return l.Value * extent / 100
+	case Inches:
+		return l.Value * dpi
+	case Centimeters:
+		return l.Value * dpi / 2.54
+	case Millimeters:
+		return l.Value * dpi / 25.4
+	case Points:
+		return l.Value * dpi / 72
+	}
+	return l.Value
+}
+
+// ResolveIn returns the length in pixels within an image of the given size;
+// since the length is not tied to either axis (e.g. a radius), percentages
+// refer to the shorter side.
+func (l Length) ResolveIn(width, height int, dpi float64) float64 {
+	return l.Resolve(float64(min(width, height)), dpi)
+}
+
+// parseLengths parses a comma-separated list of exactly n lengths.
+func parseLengths(value string, n int) ([]Length, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("invalid format: expected %d values separated by a ,", n)
 	}
-	x, err := strconv.ParseInt(parts[0], 10, 32)
+	lengths := make([]Length, n)
+	for i, part := range parts {
+		if err := lengths[i].UnmarshalFlag(part); err != nil {
+			return nil, err
+		}
+	}
+	return lengths, nil
+}
+
+// Lengths is a list of lengths, such as a dash pattern.
+type Lengths []Length
+
+// UnmarshalFlag parses a string representation of a list of lengths in the
+// format "a,b,..."; each value may have a unit, e.g. "2mm,1mm".
+func (l *Lengths) UnmarshalFlag(value string) error {
+	lengths, err := parseLengths(value, strings.Count(value, ",")+1)
 	if err != nil {
 		return err
 	}
-	y, err := strconv.ParseInt(parts[1], 10, 32)
+	*l = lengths
+	return nil
+}
+
+// MarshalFlag returns the string representation of a list of lengths.
+func (l Lengths) MarshalFlag() (string, error) {
+	parts := make([]string, len(l))
+	for i, length := range l {
+		parts[i] = length.String()
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// ResolveIn returns the lengths in pixels within an image of the given size
+// and resolution, in DPI; percentages refer to the shorter side.
+func (l Lengths) ResolveIn(width, height int, dpi float64) []float64 {
+	result := make([]float64, len(l))
+	for i, length := range l {
+		result[i] = length.ResolveIn(width, height, dpi)
+	}
+	return result
+}
+
+// Size is a 2D extent, such as the width and height of a shape.
+type Size struct {
+	X, Y Length
+}
+
+// UnmarshalFlag parses a string representation of a size in the format "x,y";
+// each value may have a unit, e.g. "50%,2cm".
+func (p *Size) UnmarshalFlag(value string) error {
+	lengths, err := parseLengths(value, 2)
 	if err != nil {
 		return err
 	}
-	p.X = int(x)
-	p.Y = int(y)
+	p.X, p.Y = lengths[0], lengths[1]
 	return nil
 }
 
 // MarshalFlag returns the string representation of a size in the format "x,y".
 func (p Size) MarshalFlag() (string, error) {
-	return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+	return fmt.Sprintf("%s,%s", p.X, p.Y), nil
+}
+
+// IsRelative returns whether any of the values is relative to the size of the image.
+func (p Size) IsRelative() bool {
+	return p.X.IsRelative() || p.Y.IsRelative()
+}
+
+// Resolve returns the size in pixels within an image of the given size and
+// resolution, in DPI.
+func (p Size) Resolve(width, height int, dpi float64) (float64, float64) {
+	return p.X.Resolve(float64(width), dpi), p.Y.Resolve(float64(height), dpi)
 }
 
 // Rectangle is a 2D rectangle; TopLeft and BottomRight are two opposite corners.
 type Rectangle struct {
-	TopLeft     Size
-	BottomRight Size
+	TopLeft     Point
+	BottomRight Point
 }
 
-// UnmarshalFlag parses a string representation of a rectangle in the format "x0,y0,x1,y1"
+// UnmarshalFlag parses a string representation of a rectangle in the format "x0,y0,x1,y1";
+// each value may have a unit, and negative values are measured from the right or bottom edge.
 // It uses flag.Value interface so that it can be used as a flag in the command line.
 func (r *Rectangle) UnmarshalFlag(value string) error {
-	parts := strings.Split(value, ",")
-
-	if len(parts) != 4 {
-		return errors.New("invalid format: expected four numbers separated by a ,")
-	}
-	x0, err := strconv.ParseInt(parts[0], 10, 32)
+	lengths, err := parseLengths(value, 4)
 	if err != nil {
 		return err
 	}
-	y0, err := strconv.ParseInt(parts[1], 10, 32)
-	if err != nil {
-		return err
-	}
-	x1, err := strconv.ParseInt(parts[2], 10, 32)
+	r.TopLeft = Point{X: lengths[0], Y: lengths[1]}
+	r.BottomRight = Point{X: lengths[2], Y: lengths[3]}
+	return nil
+}
+
+// MarshalFlag returns the string representation of a rectangle in the format "x0,y0,x1,y1".
+func (r Rectangle) MarshalFlag() (string, error) {
+	return fmt.Sprintf("%s,%s,%s,%s", r.TopLeft.X, r.TopLeft.Y, r.BottomRight.X, r.BottomRight.Y), nil
+}
+
+// Resolve returns the rectangle in pixels within an image of the given size
+// and resolution, in DPI.
+func (r Rectangle) Resolve(width, height int, dpi float64) image.Rectangle {
+	x0, y0 := r.TopLeft.Resolve(width, height, dpi)
+	x1, y1 := r.BottomRight.Resolve(width, height, dpi)
+	return image.Rect(int(math.Round(x0)), int(math.Round(y0)), int(math.Round(x1)), int(math.Round(y1)))
+}
+
+// Box is a 2D rectangle given by its top-left corner and its size.
+type Box struct {
+	Point Point
+	Size  Size
+}
+
+// UnmarshalFlag parses a string representation of a box in the format "x,y,w,h";
+// each value may have a unit, and negative coordinates are measured from the right
+// or bottom edge.
+func (b *Box) UnmarshalFlag(value string) error {
+	lengths, err := parseLengths(value, 4)
 	if err != nil {
 		return err
 	}
-	y1, err := strconv.ParseInt(parts[3], 10, 32)
+	b.Point = Point{X: lengths[0], Y: lengths[1]}
+	b.Size = Size{X: lengths[2], Y: lengths[3]}
+	return nil
+}
+
+// MarshalFlag returns the string representation of a box in the format "x,y,w,h".
+func (b Box) MarshalFlag() (string, error) {
+	return fmt.Sprintf("%s,%s,%s,%s", b.Point.X, b.Point.Y, b.Size.X, b.Size.Y), nil
+}
+
+// IsZero returns whether the box has no area, e.g. because it was not specified.
+func (b Box) IsZero() bool {
+	return b.Size.X.Value == 0 || b.Size.Y.Value == 0
+}
+
+// Resolve returns the top-left corner and the size of the box in pixels within
+// an image of the given size and resolution, in DPI.
+func (b Box) Resolve(width, height int, dpi float64) (float64, float64, float64, float64) {
+	x, y := b.Point.Resolve(width, height, dpi)
+	w, h := b.Size.Resolve(width, height, dpi)
+	return x, y, w, h
+}
+
+// Point is a 2D coordinate.
+type Point struct {
+	X, Y Length
+}
+
+// UnmarshalFlag parses a string representation of a point in the format "x,y";
+// each value may have a unit, and negative values are measured from the right
+// or bottom edge, e.g. "50%,-1cm".
+func (p *Point) UnmarshalFlag(value string) error {
+	lengths, err := parseLengths(value, 2)
 	if err != nil {
 		return err
 	}
-	r.TopLeft = Size{X: int(x0), Y: int(y0)}
-	r.BottomRight = Size{X: int(x1), Y: int(y1)}
+	p.X, p.Y = lengths[0], lengths[1]
 	return nil
 }
 
-// MarshalFlag returns the string representation of a rectangle in the format "x0,y0,x1,y1".
-func (r Rectangle) MarshalFlag() (string, error) {
-	return fmt.Sprintf("%d,%d,%d,%d", r.TopLeft.X, r.TopLeft.Y, r.BottomRight.X, r.BottomRight.Y), nil
+// MarshalFlag returns the string representation of a point in the format "x,y".
+func (p Point) MarshalFlag() (string, error) {
+	return fmt.Sprintf("%s,%s", p.X, p.Y), nil
 }
 
-// Point is a 2D coordinate as floats.
-type Point struct {
+// Resolve returns the coordinates in pixels within an image of the given size
+// and resolution, in DPI.
+func (p Point) Resolve(width, height int, dpi float64) (float64, float64) {
+	x := p.X.Resolve(float64(width), dpi)
+	if x < 0 {
+		x += float64(width)
+	}
+	y := p.Y.Resolve(float64(height), dpi)
+	if y < 0 {
+		y += float64(height)
+	}
+	return x, y
+}
+
+// Pair is a pair of plain numbers, such as the start and end angles of an arc.
+type Pair struct {
 	X, Y float64
 }
 
-// UnmarshalFlag parses a string representation of a point in the format "x,y".
-func (p *Point) UnmarshalFlag(value string) error {
+// UnmarshalFlag parses a string representation of a pair in the format "x,y".
+func (p *Pair) UnmarshalFlag(value string) error {
 	parts := strings.Split(value, ",")
 
 	if len(parts) != 2 {
@@ -102,7 +307,36 @@ func (p *Point) UnmarshalFlag(value string) error {
 	return nil
 }
 
-// MarshalFlag returns the string representation of a point in the format "x,y".
-func (p Point) MarshalFlag() (string, error) {
+// MarshalFlag returns the string representation of a pair in the format "x,y".
+func (p Pair) MarshalFlag() (string, error) {
 	return fmt.Sprintf("%g,%g", p.X, p.Y), nil
 }
+
+// Scale is a pair of scale factors, along the x and y axes.
+type Scale struct {
+	X, Y float64
+}
+
+// UnmarshalFlag parses a string representation of a scale in the format
+// "x,y", or "s" for the same factor along both axes.
+func (s *Scale) UnmarshalFlag(value string) error {
+	if !strings.Contains(value, ",") {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		s.X, s.Y = factor, factor
+		return nil
+	}
+	var p Pair
+	if err := p.UnmarshalFlag(value); err != nil {
+		return err
+	}
+	s.X, s.Y = p.X, p.Y
+	return nil
+}
+
+// MarshalFlag returns the string representation of a scale in the format "x,y".
+func (s Scale) MarshalFlag() (string, error) {
+	return fmt.Sprintf("%g,%g", s.X, s.Y), nil
+}
@@ -0,0 +1,81 @@
+package base
+
+import (
+	"image"
+	"io"
+	"os"
+)
+
+// Stage is implemented by commands that can be run against an in-memory
+// image, so that several of them can be chained with a single decode of the
+// input and a single encode of the output.
+type Stage interface {
+	// Apply runs the command against the given image and returns the result;
+	// commands that do not modify the image return it unchanged.
+	Apply(img image.Image) (image.Image, error)
+}
+
+// Source describes the input image a command is run against, e.g. for the
+// variables of text templates.
+type Source struct {
+	// Path is the name of the input file; it is empty if the image was read
+	// from STDIN.
+	Path string
+	// EXIF holds the EXIF fields of the image, by name.
+	EXIF map[string]string
+	// Resolution is the resolution of the image, in DPI, as recorded in its
+	// metadata; it is zero if the image carries no resolution information.
+	Resolution float64
+}
+
+// InputReader is implemented by commands that can read an input image; it is
+// provided by embedding an InputCommand, and overridden by commands that only
+// need the input image in some cases.
+type InputReader interface {
+	// NeedsInput returns whether the command needs an input image.
+	NeedsInput() bool
+}
+
+// SourceInheritor is implemented by commands whose output depends on the input
+// image file, e.g. on its name or metadata; it is provided by embedding an
+// InputCommand.
+type SourceInheritor interface {
+	// InheritSource sets the description of the input image.
+	InheritSource(source Source)
+}
+
+// ResolutionInheritor is implemented by commands whose output depends on the
+// resolution of the input image; it is provided by embedding an OutputCommand.
+type ResolutionInheritor interface {
+	// InheritResolution sets the resolution of the input image, in DPI.
+	InheritResolution(dpi float64)
+}
+
+// ReportInheritor is implemented by commands that print information about the
+// image; it is provided by embedding a Report.
+type ReportInheritor interface {
+	// InheritReport sets the writer the information is printed to.
+	InheritReport(w io.Writer)
+}
+
+// Report is embedded by commands that print information about the image, so
+// that a chain writing the encoded image to STDOUT can redirect it elsewhere.
+type Report struct {
+	// report is the writer the information is printed to; if nil, STDOUT.
+	report io.Writer
+}
+
+// InheritReport sets the writer the information is printed to, when the
+// command is run by another command (e.g. a chain).
+func (r *Report) InheritReport(w io.Writer) {
+	r.report = w
+}
+
+// Reporter returns the writer the information is printed to, which is STDOUT
+// unless another one was inherited.
+func (r *Report) Reporter() io.Writer {
+	if r.report == nil {
+		return os.Stdout
+	}
+	return r.report
+}
@@ -0,0 +1,139 @@
+package base
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dihedron/overlay/pipeline"
+	"github.com/jessevdk/go-flags"
+)
+
+// TextLayout holds the options that determine the text and how it is shaped
+// and laid out, shared by the commands that draw and measure text.
+type TextLayout struct {
+	// Text is the text to write as an overlay to the image.
+	Text string `short:"t" long:"text" description:"The text to add as an overlay to the given image; \\n starts a new line" optional:"true"`
+	// TextFile is the file the text is read from, as an alternative to Text.
+	TextFile flags.Filename `long:"text-file" description:"The name of the file containing the text to add as an overlay, or - for STDIN" optional:"true"`
+	// Template is whether the text is expanded as a template.
+	Template bool `long:"template" description:"Expand {{ ... }} actions in the text as a Go template, with the variables .File, .Path, .Width and .Height and the functions exif, exifTime, now and env (e.g. {{ exif \"Model\" }}, {{ now.Format \"2006-01-02\" }}, {{ env \"USER\" \"nobody\" }} or {{ .File }}); without it, the text is written as it is"`
+	// Markup is whether the text contains formatting tags.
+	Markup bool `long:"markup" description:"Interpret tags in the text: <b>bold</b>, <i>italic</i>, <colour=#RRGGBB>coloured</colour> (or <color=...>) and <size=N>sized</size>, which can be nested; use &lt;, &gt; and &amp; for <, > and &"`
+	// Font is the chain of fonts to use for writing to the image.
+	Font []flags.Filename `short:"f" long:"font" description:"The font to be used for writing, either as the name of its file or as its family and style (e.g. \"DejaVu Sans:bold\"); repeat it to give fallback fonts, used for the characters the previous ones lack; by default, the built-in Go font is used" optional:"true"`
+	// Size is the size of font to use for writing to the image.
+	Size float64 `short:"s" long:"size" description:"The size of the font to be used for writing" optional:"true" default:"12"`
+	// LetterSpacing is the extra space between characters.
+	LetterSpacing Length `long:"letter-spacing" description:"The extra space added between characters (tracking); it can be negative to tighten the text" optional:"true" default:"0"`
+	// Kerning is whether pairs of characters are kerned.
+	Kerning string `long:"kerning" description:"Whether the spacing of pairs of characters is adjusted as specified by the font" optional:"true" choice:"on" choice:"off" default:"on"`
+	// Features are the OpenType features to enable or disable.
+	Features string `long:"features" description:"A comma-separated list of OpenType features to enable (e.g. smcp,tnum), disable (e.g. -liga) or set to a value (e.g. salt=2)" optional:"true"`
+	// Variation is the list of axis values of variable fonts.
+	Variation string `long:"variation" description:"A comma-separated list of axis values of variable fonts (e.g. wght:650,wdth:80); fonts that are not variable ignore it" optional:"true"`
+	// Direction is the base direction of the paragraphs.
+	Direction string `long:"direction" description:"The base direction of the paragraphs, which determines the order of runs of text in different directions; by default, it is detected from the first letter of each paragraph" optional:"true" choice:"auto" choice:"ltr" choice:"rtl" default:"auto"`
+	// Width is the width of the text box, beyond which lines are wrapped.
+	Width Length `long:"width" description:"The width of the text box, beyond which lines are wrapped at word boundaries; by default, lines are only broken at newlines" optional:"true"`
+	// Alignment is the alignment of the lines within the text box.
+	Alignment string `long:"align" description:"The alignment of the lines within the text box; by default, lines are aligned to the left, or to the right in right-to-left paragraphs" optional:"true" choice:"left" choice:"center" choice:"right" choice:"justify"`
+	// LineHeight is the spacing between lines, as a multiple of the font line height.
+	LineHeight float64 `long:"line-height" description:"The distance between consecutive lines, as a multiple of the line height of the font" optional:"true" default:"1"`
+}
+
+// Content returns the text, either read from the text file or given on the
+// command line, where the \n escape sequence starts a new line; if requested,
+// it is expanded as a template with the data of the given source image and
+// its size.
+func (t *TextLayout) Content(source Source, width, height int) (string, error) {
+	text, err := t.read()
+	if err != nil || !t.Template {
+		return text, err
+	}
+	data := pipeline.TemplateData{Path: source.Path, Width: width, Height: height, EXIF: source.EXIF}
+	if source.Path != "" {
+		data.File = filepath.Base(source.Path)
+	}
+	expanded, err := pipeline.ExpandTemplate(text, data)
+	if err != nil {
+		slog.Error("error expanding text template", "text", text, "error", err)
+		return "", err
+	}
+	return expanded, nil
+}
+
+// read returns the text, either read from the text file or given on the
+// command line.
+func (t *TextLayout) read() (string, error) {
+	if t.TextFile == "" {
+		return strings.ReplaceAll(t.Text, `\n`, "\n"), nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if t.TextFile == "-" {
+		slog.Debug("reading text from STDIN")
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		slog.Debug("reading text from file", "name", t.TextFile)
+		data, err = os.ReadFile(string(t.TextFile))
+	}
+	if err != nil {
+		slog.Error("error reading text file", "name", t.TextFile, "error", err)
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Style loads the fonts, or the built-in one if none is given, and returns
+// the style the text is laid out with on an image of the given size and
+// resolution, in DPI; the colour and the effects are left to the caller.
+func (t *TextLayout) Style(width, height int, dpi float64) (pipeline.TextStyle, error) {
+	fonts := make([]*pipeline.Font, 0, len(t.Font))
+	for _, name := range t.Font {
+		font, err := pipeline.FindFont(string(name))
+		if err != nil {
+			slog.Error("error loading font", "name", name, "error", err)
+			return pipeline.TextStyle{}, err
+		}
+		fonts = append(fonts, font)
+	}
+	if len(fonts) == 0 {
+		font, err := pipeline.DefaultFont()
+		if err != nil {
+			slog.Error("error loading default font", "error", err)
+			return pipeline.TextStyle{}, err
+		}
+		fonts = append(fonts, font)
+	}
+
+	features, err := pipeline.ParseFeatures(t.Features)
+	if err != nil {
+		slog.Error("error parsing font features", "features", t.Features, "error", err)
+		return pipeline.TextStyle{}, err
+	}
+	variations, err := pipeline.ParseVariations(t.Variation)
+	if err != nil {
+		slog.Error("error parsing font variations", "variation", t.Variation, "error", err)
+		return pipeline.TextStyle{}, err
+	}
+
+	return pipeline.TextStyle{
+		Fonts:         fonts,
+		Size:          t.Size,
+		Markup:        t.Markup,
+		LetterSpacing: t.LetterSpacing.ResolveIn(width, height, dpi),
+		NoKerning:     t.Kerning == "off",
+		Features:      features,
+		Variations:    variations,
+		Direction:     pipeline.Direction(t.Direction),
+		Width:         t.Width.Resolve(float64(width), dpi),
+		Align:         pipeline.Alignment(t.Alignment),
+		LineHeight:    t.LineHeight,
+	}, nil
+}
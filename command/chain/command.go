@@ -0,0 +1,201 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/command/draw"
+	"github.com/dihedron/overlay/command/info"
+	"github.com/dihedron/overlay/command/transform"
+	"github.com/jessevdk/go-flags"
+)
+
+// Chain is the command that runs several draw, transform and info
+// subcommands against the same in-memory image, so that the input is
+// decoded only once and only the result of the last stage is encoded.
+type Chain struct {
+	base.InputCommand
+	base.OutputCommand
+	// Delimiter is the argument that separates the stages on the command line.
+	Delimiter string `short:"D" long:"delimiter" description:"The argument that separates the stages of the chain" optional:"true" default:"+"`
+}
+
+// stages is the set of command groups that can be used as chain stages.
+type stages struct {
+	// Draw is the set of subcommands to create a canvas and to paint images, shapes and text over it.
+	Draw draw.Commands `command:"draw" alias:"d" description:"Paint images, shapes and text over a canvas"`
+	// Info is the set of subcommands to get information about an image.
+	Info info.Commands `command:"info" alias:"i" description:"Get information about an image"`
+	// Transform is the set of subcommands to transform images.
+	Transform transform.Commands `command:"transform" alias:"x" description:"Transform images"`
+}
+
+// Execute is the real implementation of the Chain command.
+func (cmd *Chain) Execute(args []string) error {
+	slog.Debug("running chain command", "args", args)
+
+	// split the command line into stages and parse each of them
+	pipeline := []base.Stage{}
+	for i, stage := range split(args, cmd.Delimiter) {
+		s, err := parse(stage)
+		if err != nil {
+			slog.Error("invalid chain stage", "index", i, "args", stage, "error", err)
+			return fmt.Errorf("stage %d: %w", i, err)
+		}
+		pipeline = append(pipeline, s)
+	}
+	if len(pipeline) == 0 {
+		slog.Error("no stages in chain")
+		return errors.New("at least one stage must be specified")
+	}
+
+	// the input stream is not read if the first stage creates its own image
+	// (e.g. draw canvas, or draw scene with a base); stages that read an input
+	// embed an InputCommand
+	var (
+		img image.Image
+		err error
+	)
+	if r, ok := pipeline[0].(base.InputReader); ok && r.NeedsInput() {
+		if img, err = cmd.ReadInput(); err != nil {
+			slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+			return err
+		}
+		cmd.InheritResolution(cmd.InputResolution())
+	}
+
+	// all stages share the resolution of the chain output and the
+	// description of its input, which carries the resolution recorded in the
+	// input file, if any; the information printed by each stage is collected,
+	// so that it can be written on a line of its own once the stage is done
+	var reporter io.Writer = os.Stdout
+	if cmd.Output == "-" {
+		// the image is written to STDOUT, which must not be corrupted
+		reporter = os.Stderr
+	}
+	reports := map[int]*bytes.Buffer{}
+	for i, stage := range pipeline {
+		if r, ok := stage.(base.ReportInheritor); ok {
+			reports[i] = &bytes.Buffer{}
+			r.InheritReport(reports[i])
+		}
+		if r, ok := stage.(base.ResolutionInheritor); ok {
+			r.InheritResolution(cmd.OutputResolution())
+		}
+		if s, ok := stage.(base.SourceInheritor); ok {
+			s.InheritSource(cmd.InputSource())
+		}
+	}
+
+	// run all stages against the in-memory image
+	for i, stage := range pipeline {
+		slog.Debug("running chain stage", "index", i, "type", fmt.Sprintf("%T", stage))
+		if img, err = stage.Apply(img); err != nil {
+			slog.Error("error running chain stage", "index", i, "error", err)
+			return fmt.Errorf("stage %d: %w", i, err)
+		}
+		if r, ok := reports[i]; ok {
+			if err := report(reporter, r.Bytes()); err != nil {
+				slog.Error("error writing chain stage report", "index", i, "error", err)
+				return err
+			}
+		}
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// report writes the information printed by a stage, ending it with a newline
+// so that the reports of consecutive stages do not run together.
+func report(w io.Writer, printed []byte) error {
+	if len(printed) == 0 {
+		return nil
+	}
+	if !bytes.HasSuffix(printed, []byte("\n")) {
+		printed = append(printed, '\n')
+	}
+	_, err := w.Write(printed)
+	return err
+}
+
+// split breaks the command line arguments into groups separated by the
+// given delimiter; empty groups are discarded.
+func split(args []string, delimiter string) [][]string {
+	groups := [][]string{}
+	current := []string{}
+	for _, arg := range args {
+		if arg == delimiter {
+			if len(current) > 0 {
+				groups = append(groups, current)
+			}
+			current = []string{}
+			continue
+		}
+		current = append(current, arg)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// chainOnly are the long names of the flags that stages get from their input
+// and output options, but that only the chain honours, since it reads and
+// writes the image on behalf of all stages.
+var chainOnly = []string{
+	"input",
+	"output",
+	"format",
+	"jpeg-quality",
+	"png-compression",
+	"gif-colours",
+	"gif-quantizer",
+	"gif-dither",
+	"tiff-compression",
+}
+
+// parse parses the arguments of a single stage into the corresponding
+// subcommand, without executing it.
+func parse(args []string) (base.Stage, error) {
+	var stage base.Stage
+	parser := flags.NewParser(&stages{}, flags.HelpFlag)
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		s, ok := command.(base.Stage)
+		if !ok {
+			return fmt.Errorf("command %T cannot be used in a chain", command)
+		}
+		stage = s
+		return nil
+	}
+	if _, err := parser.ParseArgs(args); err != nil {
+		return nil, err
+	}
+	if stage == nil {
+		return nil, errors.New("no command specified")
+	}
+
+	// flags that would be silently ignored are rejected
+	command := parser.Active
+	for command.Active != nil {
+		command = command.Active
+	}
+	for _, name := range chainOnly {
+		if option := command.FindOptionByLongName(name); option != nil && option.IsSet() && !option.IsSetDefault() {
+			return nil, fmt.Errorf("flag --%s can only be given to the chain, not to its stages", name)
+		}
+	}
+	return stage, nil
+}
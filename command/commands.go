@@ -1,6 +1,7 @@
 package command
 
 import (
+	"github.com/dihedron/overlay/command/chain"
 	"github.com/dihedron/overlay/command/draw"
 	"github.com/dihedron/overlay/command/info"
 	"github.com/dihedron/overlay/command/transform"
@@ -9,6 +10,8 @@ import (
 
 // Commands is the set of root command groups.
 type Commands struct {
+	// Chain runs several subcommands against the same in-memory image.
+	Chain chain.Chain `command:"chain" alias:"c" description:"Run several draw, transform and info subcommands, separated by a delimiter, against the same image" pass-after-non-option:"yes"`
 	// Draw is the set of subcommands to create a canvas and to paint images, shapes and text over it.
 	Draw draw.Commands `command:"draw" alias:"d" description:"Paint images, shapes and text over a canvas"`
 	// Info is the set of subcommands to get information about an image.
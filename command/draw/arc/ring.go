@@ -0,0 +1,203 @@
+package arc
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// CircularRing is the command that adds a ring (or donut), or a segment of it,
+// as an overlay to an image.
+type CircularRing struct {
+	base.InputCommand
+	base.OutputCommand
+	// Point is the centre of the ring.
+	Point base.Point `short:"p" long:"point" description:"The coordinates of the centre of the ring, as an (x,y) point" optional:"true"`
+	// Placement aligns the ring to the underlay image, as an alternative to the point.
+	base.Placement
+	// Colour is the colour of the ring to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the ring to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the ring should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the ring should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the ring with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Stroke is the width of the ring stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the ring stroke, when fill is false" optional:"true" default:"1"`
+	// Radius defines the outer radius of the ring.
+	Radius base.Length `short:"r" long:"radius" description:"The outer radius of the ring" optional:"true" default:"10"`
+	// InnerRadius defines the radius of the hole of the ring.
+	InnerRadius base.Length `long:"inner-radius" description:"The inner radius of the ring, i.e. that of its hole" optional:"true" default:"5"`
+	// Angle defines the angle of the ring segment.
+	Angle base.Pair `short:"a" long:"angle" description:"The angle of the ring segment, as an (start,end) angles in degrees; by default, the ring is complete" optional:"true" default:"0,360"`
+}
+
+// Execute is the real implementation of the CircularRing command.
+func (cmd *CircularRing) Execute(args []string) error {
+	slog.Debug("running circular ring command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the circular ring over the given image.
+func (cmd *CircularRing) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the circular ring on the given canvas.
+func (cmd *CircularRing) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	radius := cmd.Radius.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	inner := cmd.InnerRadius.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	// the bounding box is that of the whole circle
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*radius, 2*radius, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning circular ring", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+radius, y+radius
+	}
+	pattern, err := cmd.Pattern(x-radius, y-radius, 2*radius, 2*radius, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Ring(x, y, radius, radius, inner, inner, cmd.Angle.X, cmd.Angle.Y, style)(canvas); err != nil {
+		slog.Error("error drawing circular ring", "error", err)
+		return err
+	}
+	slog.Debug("circular ring overlaid on the image", "point", cmd.Point, "radius", cmd.Radius, "inner", cmd.InnerRadius, "angle", cmd.Angle, "colour", cmd.Colour)
+	return nil
+}
+
+// EllipticalRing is the command that adds an elliptical ring (or donut), or a
+// segment of it, as an overlay to an image.
+type EllipticalRing struct {
+	base.InputCommand
+	base.OutputCommand
+	// Point is the centre of the ring.
+	Point base.Point `short:"p" long:"point" description:"The coordinates of the centre of the ring, as an (x,y) point" optional:"true"`
+	// Placement aligns the ring to the underlay image, as an alternative to the point.
+	base.Placement
+	// Colour is the colour of the ring to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the ring to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the ring should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the ring should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the ring with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Stroke is the width of the ring stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the ring stroke, when fill is false" optional:"true" default:"1"`
+	// Radius defines the outer radii (rx and ry) of the ring.
+	Radius base.Size `short:"r" long:"radius" description:"The outer radii of the ring" optional:"true" default:"10,10"`
+	// InnerRadius defines the radii of the hole of the ring.
+	InnerRadius base.Size `long:"inner-radius" description:"The inner radii of the ring, i.e. those of its hole" optional:"true" default:"5,5"`
+	// Angle defines the angle of the ring segment.
+	Angle base.Pair `short:"a" long:"angle" description:"The angle of the ring segment, as an (start,end) angles in degrees; by default, the ring is complete" optional:"true" default:"0,360"`
+}
+
+// Execute is the real implementation of the EllipticalRing command.
+func (cmd *EllipticalRing) Execute(args []string) error {
+	slog.Debug("running elliptical ring command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the elliptical ring over the given image.
+func (cmd *EllipticalRing) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the elliptical ring on the given canvas.
+func (cmd *EllipticalRing) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	rx, ry := cmd.Radius.Resolve(canvas.Width(), canvas.Height(), dpi)
+	irx, iry := cmd.InnerRadius.Resolve(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	// the bounding box is that of the whole ellipse
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*rx, 2*ry, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning elliptical ring", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+rx, y+ry
+	}
+	pattern, err := cmd.Pattern(x-rx, y-ry, 2*rx, 2*ry, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Ring(x, y, rx, ry, irx, iry, cmd.Angle.X, cmd.Angle.Y, style)(canvas); err != nil {
+		slog.Error("error drawing elliptical ring", "error", err)
+		return err
+	}
+	slog.Debug("elliptical ring overlaid on the image", "point", cmd.Point, "radii", cmd.Radius, "inner", cmd.InnerRadius, "angle", cmd.Angle, "colour", cmd.Colour)
+	return nil
+}
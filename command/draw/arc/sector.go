@@ -0,0 +1,197 @@
+package arc
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// CircularSector is the command that adds a pie slice, i.e. an arc of a circle
+// closed by the radii at its ends, as an overlay to an image.
+type CircularSector struct {
+	base.InputCommand
+	base.OutputCommand
+	// Point is the centre of the circle the sector is cut from.
+	Point base.Point `short:"p" long:"point" description:"The coordinates of the centre of the circle the sector is cut from, as an (x,y) point" optional:"true"`
+	// Placement aligns the sector to the underlay image, as an alternative to the point.
+	base.Placement
+	// Colour is the colour of the sector to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the sector to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the sector should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the sector should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the sector with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Stroke is the width of the sector stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the sector stroke, when fill is false" optional:"true" default:"1"`
+	// Radius defines the radius of the circle.
+	Radius base.Length `short:"r" long:"radius" description:"The radius of the circle" optional:"true" default:"10"`
+	// Angle defines the angle of the sector.
+	Angle base.Pair `short:"a" long:"angle" description:"The angle of the sector, as an (start,end) angles in degrees" optional:"true" default:"0,90"`
+}
+
+// Execute is the real implementation of the CircularSector command.
+func (cmd *CircularSector) Execute(args []string) error {
+	slog.Debug("running circular sector command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the circular sector over the given image.
+func (cmd *CircularSector) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the circular sector on the given canvas.
+func (cmd *CircularSector) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	radius := cmd.Radius.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	// the bounding box is that of the whole circle
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*radius, 2*radius, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning circular sector", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+radius, y+radius
+	}
+	pattern, err := cmd.Pattern(x-radius, y-radius, 2*radius, 2*radius, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Sector(x, y, radius, radius, cmd.Angle.X, cmd.Angle.Y, style)(canvas); err != nil {
+		slog.Error("error drawing circular sector", "error", err)
+		return err
+	}
+	slog.Debug("circular sector overlaid on the image", "point", cmd.Point, "radius", cmd.Radius, "angle", cmd.Angle, "colour", cmd.Colour)
+	return nil
+}
+
+// EllipticalSector is the command that adds a pie slice, i.e. an arc of an
+// ellipse closed by the radii at its ends, as an overlay to an image.
+type EllipticalSector struct {
+	base.InputCommand
+	base.OutputCommand
+	// Point is the centre of the ellipse the sector is cut from.
+	Point base.Point `short:"p" long:"point" description:"The coordinates of the centre of the ellipse the sector is cut from, as an (x,y) point" optional:"true"`
+	// Placement aligns the sector to the underlay image, as an alternative to the point.
+	base.Placement
+	// Colour is the colour of the sector to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the sector to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the sector should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the sector should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the sector with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Stroke is the width of the sector stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the sector stroke, when fill is false" optional:"true" default:"1"`
+	// Radius defines the radii (rx and ry) of the ellipse
+	Radius base.Size `short:"r" long:"radius" description:"The radii of the ellipse" optional:"true" default:"10,10"`
+	// Angle defines the angle of the sector.
+	Angle base.Pair `short:"a" long:"angle" description:"The angle of the sector, as an (start,end) angles in degrees" optional:"true" default:"0,90"`
+}
+
+// Execute is the real implementation of the EllipticalSector command.
+func (cmd *EllipticalSector) Execute(args []string) error {
+	slog.Debug("running elliptical sector command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the elliptical sector over the given image.
+func (cmd *EllipticalSector) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the elliptical sector on the given canvas.
+func (cmd *EllipticalSector) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	rx, ry := cmd.Radius.Resolve(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	// the bounding box is that of the whole ellipse
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*rx, 2*ry, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning elliptical sector", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+rx, y+ry
+	}
+	pattern, err := cmd.Pattern(x-rx, y-ry, 2*rx, 2*ry, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Sector(x, y, rx, ry, cmd.Angle.X, cmd.Angle.Y, style)(canvas); err != nil {
+		slog.Error("error drawing elliptical sector", "error", err)
+		return err
+	}
+	slog.Debug("elliptical sector overlaid on the image", "point", cmd.Point, "radii", cmd.Radius, "angle", cmd.Angle, "colour", cmd.Colour)
+	return nil
+}
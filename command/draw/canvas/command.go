@@ -1,10 +1,13 @@
 package canvas
 
 import (
+	"errors"
+	"image"
 	"log/slog"
+	"math"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Canvas is the command that creates a new image with the given size and colour.
@@ -13,21 +16,19 @@ type Canvas struct {
 	// Size is the size of the canvas.
 	Size base.Size `short:"s" long:"size" description:"The size of the canvas, as an (width,height) pair" required:"true"`
 	// Colour is the colour used to fill the canvas.
-	Colour base.Colour `short:"c" long:"colour" description:"The colour used to fill the canvas" optional:"true" default:"#FFFFFF"`
+	Colour base.Paint `short:"c" long:"colour" description:"The colour used to fill the canvas, or a gradient such as linear(0,0,0,100%,#FF0000,#0000FF)" optional:"true" default:"#FFFFFF"`
 }
 
 // Execute is the real implementation of the Canvas command.
 func (cmd *Canvas) Execute(args []string) error {
 	slog.Debug("running canvas command")
 
-	dc := gg.NewContext(cmd.Size.X, cmd.Size.Y)
-	defer dc.Close()
-
-	// clear background with uniform colour
-	dc.ClearWithColor(gg.RGBA2(float64(cmd.Colour.R), float64(cmd.Colour.G), float64(cmd.Colour.B), float64(cmd.Colour.A)))
+	img, err := cmd.Apply(nil)
+	if err != nil {
+		return err
+	}
 
 	// write the image to the output stream
-	img := dc.Image()
 	if err := cmd.WriteOutput(img); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -36,3 +37,39 @@ func (cmd *Canvas) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply creates a new image with the given size and colour; since a canvas
+// is always the first element of a composition, the input image is ignored.
+func (cmd *Canvas) Apply(_ image.Image) (image.Image, error) {
+	canvas, err := cmd.NewCanvas()
+	if err != nil {
+		return nil, err
+	}
+	defer canvas.Close()
+
+	return canvas.Image(), nil
+}
+
+// NewCanvas creates a new canvas with the size and background colour given
+// on the command line.
+func (cmd *Canvas) NewCanvas() (*pipeline.Canvas, error) {
+	slog.Debug("creating canvas", "size", cmd.Size, "colour", cmd.Colour)
+	if cmd.Size.IsRelative() {
+		slog.Error("canvas size cannot be relative", "size", cmd.Size)
+		return nil, errors.New("the size of a canvas cannot be given as a percentage")
+	}
+	width, height := cmd.Size.Resolve(0, 0, cmd.OutputResolution())
+	canvas := pipeline.NewCanvas(int(math.Round(width)), int(math.Round(height)))
+
+	// clear background with uniform colour, or with the gradient
+	backdrop := pipeline.Backdrop(cmd.Colour)
+	if gradient := cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()); gradient != nil {
+		backdrop = pipeline.GradientBackdrop(*gradient)
+	}
+	if _, err := canvas.Apply(backdrop); err != nil {
+		slog.Error("error painting canvas background", "colour", cmd.Colour, "error", err)
+		canvas.Close()
+		return nil, err
+	}
+	return canvas, nil
+}
@@ -1,11 +1,11 @@
 package circle
 
 import (
-	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Circle is the command that adds a circle as an overlay to an image.
@@ -14,16 +14,20 @@ type Circle struct {
 	base.OutputCommand
 	// Point is the position in the image where the circle will start.
 	Point base.Point `short:"p" long:"point" description:"The coordinates where the circle will be written, as an (x,y) point" optional:"true"`
+	// Placement aligns the circle to the underlay image, as an alternative to the point.
+	base.Placement
 	// // Size is the size of the square to be written to the image.
 	// Size base.Point `short:"s" long:"size" description:"The size of the square to be written to the image, as an (width,height) point" optional:"true"`
 	// Colour is the colour of the circle to be written to the image.
-	Colour base.Colour `short:"c" long:"colour" description:"The colour of the circle to be written to the image" optional:"true" default:"#000000"`
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the circle to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
 	// Fill is whether the circle should be filled with the given colour.
 	Fill bool `short:"f" long:"fill" description:"Whether the circle should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the circle with an image or a pattern, instead of the colour.
+	base.FillPattern
 	// Stroke is the width of the circle stroke, when fill is false.
 	Stroke float64 `short:"w" long:"stroke" description:"The width of the circle stroke, when fill is false" optional:"true" default:"1"`
 	// Radius defines the radius of the circle.
-	Radius float64 `short:"r" long:"radius" description:"The radius of the circle" optional:"true" default:"10"`
+	Radius base.Length `short:"r" long:"radius" description:"The radius of the circle" optional:"true" default:"10"`
 }
 
 // Execute is the real implementation of the Circle command.
@@ -36,57 +40,14 @@ func (cmd *Circle) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	// create the device context with the underlay image
-	dc := gg.NewContextForImage(underlay)
-	defer dc.Close()
-
-	// set the colour
-	dc.SetRGBA(float64(cmd.Colour.R), float64(cmd.Colour.G), float64(cmd.Colour.B), float64(cmd.Colour.A))
-
-	slog.Debug("drawing circle", "point", cmd.Point, "radius", cmd.Radius)
-	dc.DrawCircle(cmd.Point.X, cmd.Point.Y, cmd.Radius)
-
-	if cmd.Fill {
-		slog.Debug("drawing circle as fill", "colour", cmd.Colour)
-		dc.Fill()
-	} else if cmd.Stroke > 0 {
-		slog.Debug("drawing circle as stroke", "width", cmd.Stroke)
-		dc.SetLineWidth(cmd.Stroke)
-		dc.Stroke()
-	} else {
-		slog.Error("either --fill or --stroke must be specified")
-		return fmt.Errorf("either --fill or --stroke must be specified")
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
 	}
 
-	// if cmd.Radius > 0 {
-	// 	slog.Debug("drawing rounded rectangle", "point", cmd.Point, "size", cmd.Size, "radius", cmd.Radius)
-	// 	// define the rounded rectangle area: (x1, y1) to (x2, y2)
-	// 	// rounded rectangle is defined by the top-left corner and the size
-	// 	dc.DrawRoundedRectangle(float64(cmd.Point.X), float64(cmd.Point.Y), float64(cmd.Size.X), float64(cmd.Size.Y), float64(cmd.Radius))
-	// } else {
-	// 	slog.Debug("drawing rectangle", "point", cmd.Point, "size", cmd.Size)
-	// 	// define the rectangle area: (x1, y1) to (x2, y2)
-	// 	// rectangle is defined by the top-left corner and the size
-	// 	dc.DrawRectangle(float64(cmd.Point.X), float64(cmd.Point.Y), float64(cmd.Size.X), float64(cmd.Size.Y))
-	// }
-
-	// if cmd.Fill {
-	// 	slog.Debug("drawing rectangle as fill", "colour", cmd.Colour)
-	// 	dc.Fill()
-	// } else if cmd.Stroke > 0 {
-	// 	slog.Debug("drawing rectangle as stroke", "width", cmd.Stroke)
-	// 	dc.SetLineWidth(cmd.Stroke)
-	// 	dc.Stroke()
-	// } else {
-	// 	slog.Error("either --fill or --stroke must be specified")
-	// 	return fmt.Errorf("either --fill or --stroke must be specified")
-	// }
-
-	slog.Debug("circle overlaid on the image", "point", cmd.Point, "radius", cmd.Radius, "colour", cmd.Colour)
-
 	// write the image to the output stream
-	img := dc.Image()
 	if err := cmd.WriteOutput(img); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -95,3 +56,46 @@ func (cmd *Circle) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply paints the circle over the given image.
+func (cmd *Circle) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the circle on the given canvas.
+func (cmd *Circle) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	radius := cmd.Radius.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*radius, 2*radius, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning circle", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+radius, y+radius
+	}
+	pattern, err := cmd.Pattern(x-radius, y-radius, 2*radius, 2*radius, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Circle(x, y, radius, style)(canvas); err != nil {
+		slog.Error("error drawing circle", "error", err)
+		return err
+	}
+	slog.Debug("circle overlaid on the image", "point", cmd.Point, "radius", cmd.Radius, "colour", cmd.Colour)
+	return nil
+}
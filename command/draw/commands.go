@@ -6,7 +6,10 @@ import (
 	"github.com/dihedron/overlay/command/draw/circle"
 	"github.com/dihedron/overlay/command/draw/ellipse"
 	"github.com/dihedron/overlay/command/draw/image"
+	"github.com/dihedron/overlay/command/draw/line"
+	"github.com/dihedron/overlay/command/draw/path"
 	"github.com/dihedron/overlay/command/draw/rectangle"
+	"github.com/dihedron/overlay/command/draw/scene"
 	"github.com/dihedron/overlay/command/draw/text"
 )
 
@@ -20,6 +23,14 @@ type Commands struct {
 	Circle circle.Circle `command:"circle" alias:"o" description:"Add a circle as an overlay to an image." `
 	// Ellipse adds an ellipse as an overlay to an image.
 	Ellipse ellipse.Ellipse `command:"ellipse" alias:"e" description:"Add an ellipse as an overlay to an image." `
+	// Line adds a straight line as an overlay to an image.
+	Line line.Line `command:"line" alias:"l" description:"Add a straight line as an overlay to an image." `
+	// Polyline adds a line through a sequence of points as an overlay to an image.
+	Polyline line.Polyline `command:"polyline" alias:"pl" description:"Add a line through a sequence of points as an overlay to an image." `
+	// Polygon adds a closed polygon as an overlay to an image.
+	Polygon line.Polygon `command:"polygon" alias:"pg" description:"Add a closed polygon as an overlay to an image." `
+	// Path adds a shape described by SVG path data as an overlay to an image.
+	Path path.Path `command:"path" alias:"pa" description:"Add a shape described by SVG path data as an overlay to an image." `
 	// Image superimposes an image as an overlay to the given image.
 	Image image.Image `command:"image" alias:"i" description:"Superimposes an image as an overlay to the given image." `
 	// Text adds text as an overlay to an image.
@@ -27,5 +38,15 @@ type Commands struct {
 	// CircularArc adds a circular arc as an overlay to an image.
 	CircularArc arc.CircularArc `command:"circular-arc" alias:"a" description:"Add a circular arc as an overlay to an image." `
 	// EllipticalArc adds an elliptical arc as an overlay to an image.
-	//TODO: EllipticalArc arc.EllipticalArc `command:"elliptical-arc" alias:"ea" description:"Add an elliptical arc as an overlay to an image." `
+	EllipticalArc arc.EllipticalArc `command:"elliptical-arc" alias:"ea" description:"Add an elliptical arc as an overlay to an image." `
+	// CircularSector adds a pie slice of a circle as an overlay to an image.
+	CircularSector arc.CircularSector `command:"circular-sector" alias:"cs" description:"Add a pie slice of a circle as an overlay to an image." `
+	// EllipticalSector adds a pie slice of an ellipse as an overlay to an image.
+	EllipticalSector arc.EllipticalSector `command:"elliptical-sector" alias:"es" description:"Add a pie slice of an ellipse as an overlay to an image." `
+	// CircularRing adds a ring, or a segment of it, as an overlay to an image.
+	CircularRing arc.CircularRing `command:"circular-ring" alias:"cr" description:"Add a ring, or a segment of it, as an overlay to an image." `
+	// EllipticalRing adds an elliptical ring, or a segment of it, as an overlay to an image.
+	EllipticalRing arc.EllipticalRing `command:"elliptical-ring" alias:"er" description:"Add an elliptical ring, or a segment of it, as an overlay to an image." `
+	// Scene renders a whole composition described by a YAML or JSON document.
+	Scene scene.Scene `command:"scene" alias:"s" description:"Render a whole composition described by a YAML or JSON document." `
 }
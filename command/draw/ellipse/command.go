@@ -1,11 +1,11 @@
 package ellipse
 
 import (
-	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Ellipse is the command that adds an ellipse as an overlay to an image.
@@ -14,14 +14,18 @@ type Ellipse struct {
 	base.OutputCommand
 	// Point is the position in the image where the ellipse will start.
 	Point base.Point `short:"p" long:"point" description:"The coordinates where the ellipse will be written, as an (x,y) point" optional:"true"`
+	// Placement aligns the ellipse to the underlay image, as an alternative to the point.
+	base.Placement
 	// Colour is the colour of the ellipse to be written to the image.
-	Colour base.Colour `short:"c" long:"colour" description:"The colour of the ellipse to be written to the image" optional:"true" default:"#000000"`
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the ellipse to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
 	// Fill is whether the ellipse should be filled with the given colour.
 	Fill bool `short:"f" long:"fill" description:"Whether the ellipse should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the ellipse with an image or a pattern, instead of the colour.
+	base.FillPattern
 	// Stroke is the width of the ellipse stroke, when fill is false.
 	Stroke float64 `short:"w" long:"stroke" description:"The width of the ellipse stroke, when fill is false" optional:"true" default:"1"`
 	// Radius defines the radii (rx and ry) of the ellipse
-	Radius base.Point `short:"r" long:"radius" description:"The radii of the ellipse" optional:"true" default:"10,10"`
+	Radius base.Size `short:"r" long:"radius" description:"The radii of the ellipse" optional:"true" default:"10,10"`
 }
 
 // Execute is the real implementation of the Rectangle command.
@@ -34,32 +38,14 @@ func (cmd *Ellipse) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	// create the device context with the underlay image
-	dc := gg.NewContextForImage(underlay)
-	defer dc.Close()
-
-	// set the colour
-	dc.SetRGBA(float64(cmd.Colour.R), float64(cmd.Colour.G), float64(cmd.Colour.B), float64(cmd.Colour.A))
-
-	dc.DrawEllipse(cmd.Point.X, cmd.Point.Y, cmd.Radius.X, cmd.Radius.Y)
-
-	if cmd.Fill {
-		slog.Debug("drawing ellipse as fill", "colour", cmd.Colour)
-		dc.Fill()
-	} else if cmd.Stroke > 0 {
-		slog.Debug("drawing ellipse as stroke", "width", cmd.Stroke)
-		dc.SetLineWidth(cmd.Stroke)
-		dc.Stroke()
-	} else {
-		slog.Error("either --fill or --stroke must be specified")
-		return fmt.Errorf("either --fill or --stroke must be specified")
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
 	}
 
-	slog.Debug("ellipse overlaid on the image", "point", cmd.Point, "radii", cmd.Radius, "colour", cmd.Colour)
-
 	// write the image to the output stream
-	img := dc.Image()
 	if err := cmd.WriteOutput(img); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -68,3 +54,46 @@ func (cmd *Ellipse) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply paints the ellipse over the given image.
+func (cmd *Ellipse) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the ellipse on the given canvas.
+func (cmd *Ellipse) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	rx, ry := cmd.Radius.Resolve(canvas.Width(), canvas.Height(), dpi)
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(2*rx, 2*ry, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning ellipse", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		x, y = x+rx, y+ry
+	}
+	pattern, err := cmd.Pattern(x-rx, y-ry, 2*rx, 2*ry, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Ellipse(x, y, rx, ry, style)(canvas); err != nil {
+		slog.Error("error drawing ellipse", "error", err)
+		return err
+	}
+	slog.Debug("ellipse overlaid on the image", "point", cmd.Point, "radii", cmd.Radius, "colour", cmd.Colour)
+	return nil
+}
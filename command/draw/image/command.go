@@ -1,13 +1,13 @@
 package image
 
 import (
-	"errors"
+	"fmt"
 	"image"
 	"log/slog"
-	"os"
+	"math"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
+	"github.com/dihedron/overlay/pipeline"
 	"github.com/jessevdk/go-flags"
 )
 
@@ -16,9 +16,23 @@ type Image struct {
 	base.OutputCommand
 	base.InputCommand
 	// Image is the image to superimpose as an overlay to the image.
-	Image flags.Filename `short:"y" long:"image" description:"The image to superimpose as an overlay to the given image" optional:"true"`
+	Image flags.Filename `short:"y" long:"image" description:"The image to superimpose as an overlay to the given image; SVG documents are drawn as vector graphics" optional:"true"`
+	// Size is the size of the box the overlay is fitted into.
+	Size base.Size `long:"size" description:"The size of the box the overlay is fitted into, as a (width,height) pair; if either is 0, it is computed from the other keeping the aspect ratio; by default, the natural size of the overlay" optional:"true"`
+	// Scale is the factor the natural size of the overlay is scaled by.
+	Scale base.Scale `long:"scale" description:"The factor the natural size of the overlay is scaled by, or the factors along the x and y axes as an (x,y) pair; it is ignored if the size is given" optional:"true" default:"1"`
+	// Fit is how the overlay is fitted into the box given by the size.
+	Fit string `long:"fit" description:"How the overlay is fitted into the box given by the size: whole within it (fit), covering it and cropped to it (fill), both keeping the aspect ratio, or stretched to it (stretch)" optional:"true" choice:"fit" choice:"fill" choice:"stretch" default:"fit"`
+	// Filter is the resampling filter used to resize raster images.
+	Filter string `long:"filter" description:"The resampling filter used to resize raster overlays" optional:"true" choice:"nearest" choice:"box" choice:"linear" choice:"gaussian" choice:"mitchell" choice:"catmull-rom" choice:"lanczos" default:"linear"`
+	// Angle is the rotation of the overlay around its centre.
+	Angle float64 `long:"angle" description:"The rotation of the overlay in degrees, clockwise, around its centre; the bounding box of the rotated overlay is positioned" optional:"true" default:"0"`
+	// Opacity is the opacity of the overlay.
+	Opacity float64 `long:"opacity" description:"The opacity of the overlay, from 0 (fully transparent) to 1 (opaque)" optional:"true" default:"1"`
 	// Point is the position in the image where the image will be superimposed.
 	Point base.Point `short:"p" long:"point" description:"The coordinates where the image will be superimposed, as an (x,y) point" optional:"true"`
+	// Placement aligns the image to the underlay image, as an alternative to the point.
+	base.Placement
 }
 
 // Execute is the real implementation of the Image command.
@@ -31,46 +45,149 @@ func (cmd *Image) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 	slog.Debug("underlay image decoded", "name", cmd.Input, "width", underlay.Bounds().Dx(), "height", underlay.Bounds().Dy())
 
-	// open the overlay image file
-	slog.Debug("reading overlay from file", "name", cmd.Image)
-	var f *os.File
-	if f, err = os.Open(string(cmd.Image)); err != nil {
-		slog.Error("error opening overlay image file", "name", cmd.Image, "error", err)
+	img, err := cmd.Apply(underlay)
+	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	// decode the overlay image
-	overlay, _, err := image.Decode(f)
-	if err != nil {
-		slog.Error("error decoding input data for overlay image", "name", cmd.Image, "error", err)
-		os.Exit(1)
+	// write the result to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
 	}
-	slog.Debug("overlay image decoded", "name", cmd.Image, "width", overlay.Bounds().Dx(), "height", overlay.Bounds().Dy())
 
-	// check if the overlay image is larger than the underlay image
-	if overlay.Bounds().Dx() > underlay.Bounds().Dx() || overlay.Bounds().Dy() > underlay.Bounds().Dy() {
-		slog.Error("overlay image is larger than the underlay image", "name", cmd.Image)
-		return errors.New("overlay image is larger than the underlay image")
+	slog.Debug("command done")
+	return nil
+}
+
+// Apply paints the overlay over the given image.
+func (cmd *Image) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
 	}
-	slog.Debug("overlay image is smaller than the underlay image", "name", cmd.Image)
+	return canvas.Image(), nil
+}
 
-	// create the device context with the underlay image
-	dc := gg.NewContextForImage(underlay)
-	defer dc.Close()
+// Paint superimposes the overlay image on the given canvas; the parts of the
+// overlay past the edges of the canvas are clipped.
+func (cmd *Image) Paint(canvas *pipeline.Canvas) error {
+	if cmd.Opacity < 0 || cmd.Opacity > 1 {
+		slog.Error("invalid overlay opacity", "opacity", cmd.Opacity)
+		return fmt.Errorf("invalid overlay opacity %g: it must be between 0 and 1", cmd.Opacity)
+	}
 
-	// copy the overlay image on the underlay image at the given point
-	dc.DrawImage(gg.ImageBufFromImage(overlay), cmd.Point.X, cmd.Point.Y)
+	// read the overlay, either a raster image or an SVG document
+	var (
+		naturalWidth, naturalHeight float64
+		draw                        func(x, y, width, height float64) pipeline.Painter
+	)
+	if pipeline.IsSVG(string(cmd.Image)) {
+		slog.Debug("reading SVG overlay from file", "name", cmd.Image)
+		overlay, err := pipeline.LoadSVG(string(cmd.Image))
+		if err != nil {
+			slog.Error("error reading overlay SVG file", "name", cmd.Image, "error", err)
+			return err
+		}
+		naturalWidth, naturalHeight = overlay.Size()
+		slog.Debug("overlay SVG document parsed", "name", cmd.Image, "width", naturalWidth, "height", naturalHeight)
+		draw = func(x, y, width, height float64) pipeline.Painter {
+			return pipeline.SVGImage(overlay, x, y, width, height)
+		}
+	} else {
+		slog.Debug("reading overlay from file", "name", cmd.Image)
+		overlay, err := pipeline.Load(string(cmd.Image))
+		if err != nil {
+			slog.Error("error reading overlay image file", "name", cmd.Image, "error", err)
+			return err
+		}
+		naturalWidth, naturalHeight = float64(overlay.Bounds().Dx()), float64(overlay.Bounds().Dy())
+		slog.Debug("overlay image decoded", "name", cmd.Image, "width", naturalWidth, "height", naturalHeight)
+		draw = func(x, y, width, height float64) pipeline.Painter {
+			img := overlay
+			if w, h := int(math.Round(width)), int(math.Round(height)); w != img.Bounds().Dx() || h != img.Bounds().Dy() {
+				slog.Debug("resizing overlay image", "width", w, "height", h, "filter", cmd.Filter)
+				img = pipeline.Resize(max(w, 1), max(h, 1), pipeline.Filter(cmd.Filter))(img)
+			}
+			// raster images are kept on the pixel grid, so as not to blur them
+			return pipeline.Image(img, math.Round(x), math.Round(y))
+		}
+	}
 
-	// write the result to the output stream
-	img := dc.Image()
-	if err := cmd.WriteOutput(img); err != nil {
-		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+	// the bounding box of the rotated box is positioned, and the overlay is
+	// drawn around its centre
+	dpi := cmd.OutputResolution()
+	boxWidth, boxHeight, width, height := cmd.size(naturalWidth, naturalHeight, canvas, dpi)
+	if width < 1 || height < 1 {
+		slog.Error("invalid overlay size", "size", cmd.Size, "scale", cmd.Scale, "width", width, "height", height)
+		return fmt.Errorf("invalid overlay size %gx%g", width, height)
+	}
+	sin, cos := math.Sincos(cmd.Angle * math.Pi / 180)
+	outerWidth := math.Abs(boxWidth*cos) + math.Abs(boxHeight*sin)
+	outerHeight := math.Abs(boxWidth*sin) + math.Abs(boxHeight*cos)
+	x, y, err := cmd.position(outerWidth, outerHeight, canvas, dpi)
+	if err != nil {
 		return err
 	}
+	cx, cy := x+outerWidth/2, y+outerHeight/2
 
-	slog.Debug("command done")
+	painter := draw(cx-width/2, cy-height/2, width, height)
+	if width > boxWidth || height > boxHeight {
+		painter = pipeline.Clipped(cx-boxWidth/2, cy-boxHeight/2, boxWidth, boxHeight, painter)
+	}
+	if cmd.Angle != 0 {
+		painter = pipeline.Rotated(cmd.Angle, cx, cy, painter)
+	}
+	painter = pipeline.Translucent(cmd.Opacity, painter)
+	if err := painter(canvas); err != nil {
+		slog.Error("error drawing overlay image", "name", cmd.Image, "error", err)
+		return err
+	}
+	slog.Debug("overlay drawn", "name", cmd.Image, "x", x, "y", y, "width", width, "height", height, "angle", cmd.Angle, "opacity", cmd.Opacity)
 	return nil
 }
+
+// size returns the size of the box the overlay occupies and the size the
+// overlay is drawn at, given its natural size; they only differ when the
+// overlay fills the box, and is then cropped to it.
+func (cmd *Image) size(width, height float64, canvas *pipeline.Canvas, dpi float64) (float64, float64, float64, float64) {
+	w, h := cmd.Size.Resolve(canvas.Width(), canvas.Height(), dpi)
+	switch {
+	case w > 0 && h > 0:
+		switch cmd.Fit {
+		case "stretch":
+			return w, h, w, h
+		case "fill":
+			scale := max(w/width, h/height)
+			return w, h, width * scale, height * scale
+		default:
+			scale := min(w/width, h/height)
+			return width * scale, height * scale, width * scale, height * scale
+		}
+	case w > 0:
+		return w, height * w / width, w, height * w / width
+	case h > 0:
+		return width * h / height, h, width * h / height, h
+	}
+	return width * cmd.Scale.X, height * cmd.Scale.Y, width * cmd.Scale.X, height * cmd.Scale.Y
+}
+
+// position returns the top-left corner of an overlay of the given size,
+// either at the point or aligned to the anchor.
+func (cmd *Image) position(width, height float64, canvas *pipeline.Canvas, dpi float64) (float64, float64, error) {
+	if !cmd.IsAnchored() {
+		x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+		return x, y, nil
+	}
+	x, y, err := cmd.Align(width, height, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		slog.Error("error aligning overlay image", "anchor", cmd.Anchor, "error", err)
+		return 0, 0, err
+	}
+	return x, y, nil
+}
@@ -0,0 +1,89 @@
+package line
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Line is the command that adds a straight line as an overlay to an image.
+type Line struct {
+	base.InputCommand
+	base.OutputCommand
+	// Points are the ends of the line.
+	Points []base.Point `short:"p" long:"point" description:"The coordinates of an end of the line, as an (x,y) point; give it twice, for the start and the end" required:"true"`
+	// Colour is the colour of the line to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the line to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Stroke is the width of the line stroke.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the line stroke" optional:"true" default:"1"`
+	// LineStyle is the cap, join and dash pattern of the stroke.
+	base.LineStyle
+	// Arrow selects the ends of the line that carry an arrowhead.
+	Arrow string `short:"a" long:"arrow" description:"The ends of the line that carry an arrowhead" optional:"true" choice:"none" choice:"start" choice:"end" choice:"both" default:"none"`
+	// ArrowSize is the length of the arrowheads.
+	ArrowSize base.Length `long:"arrow-size" description:"The length of the arrowheads; by default, four times the width of the stroke, but at least 10 pixels" optional:"true" default:"0"`
+}
+
+// Execute is the real implementation of the Line command.
+func (cmd *Line) Execute(args []string) error {
+	slog.Debug("running line command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the line over the given image.
+func (cmd *Line) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the line on the given canvas.
+func (cmd *Line) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	cmd.Configure(&style, canvas.Width(), canvas.Height(), dpi)
+	points := resolve(cmd.Points, canvas.Width(), canvas.Height(), dpi)
+	if len(points) != 2 {
+		slog.Error("invalid number of points for line", "points", len(points))
+		return fmt.Errorf("%w: a line needs exactly 2, got %d", pipeline.ErrTooFewPoints, len(points))
+	}
+	size := arrowSize(cmd.ArrowSize, cmd.Stroke, canvas, dpi)
+	if err := pipeline.Polyline(points, pipeline.Arrows(cmd.Arrow), size, style)(canvas); err != nil {
+		slog.Error("error drawing line", "error", err)
+		return err
+	}
+	slog.Debug("line overlaid on the image", "points", cmd.Points, "arrow", cmd.Arrow, "colour", cmd.Colour)
+	return nil
+}
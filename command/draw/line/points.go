@@ -0,0 +1,25 @@
+package line
+
+import (
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// resolve returns the coordinates in pixels of the given points, within an
+// image of the given size and resolution, in DPI.
+func resolve(points []base.Point, width, height int, dpi float64) [][2]float64 {
+	result := make([][2]float64, len(points))
+	for i, p := range points {
+		result[i][0], result[i][1] = p.Resolve(width, height, dpi)
+	}
+	return result
+}
+
+// arrowSize returns the length of the arrowheads in pixels: the given one, or
+// one proportional to the width of the stroke if it is zero.
+func arrowSize(size base.Length, stroke float64, canvas *pipeline.Canvas, dpi float64) float64 {
+	if size.Value > 0 {
+		return size.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	}
+	return max(10, 4*stroke)
+}
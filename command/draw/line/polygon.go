@@ -0,0 +1,82 @@
+package line
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Polygon is the command that adds a closed polygon as an overlay to an image.
+type Polygon struct {
+	base.InputCommand
+	base.OutputCommand
+	// Points are the vertices of the polygon.
+	Points []base.Point `short:"p" long:"point" description:"The coordinates of a vertex of the polygon, as an (x,y) point; repeat it for each vertex, in order" required:"true"`
+	// Colour is the colour of the polygon to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the polygon to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the polygon should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the polygon should be filled with the given colour, by default it is not" optional:"true"`
+	// Stroke is the width of the polygon stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the polygon stroke, when fill is false" optional:"true" default:"1"`
+	// LineStyle is the cap, join and dash pattern of the stroke.
+	base.LineStyle
+}
+
+// Execute is the real implementation of the Polygon command.
+func (cmd *Polygon) Execute(args []string) error {
+	slog.Debug("running polygon command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the polygon over the given image.
+func (cmd *Polygon) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the polygon on the given canvas.
+func (cmd *Polygon) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill,
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	cmd.Configure(&style, canvas.Width(), canvas.Height(), dpi)
+	points := resolve(cmd.Points, canvas.Width(), canvas.Height(), dpi)
+	if err := pipeline.Polygon(points, style)(canvas); err != nil {
+		slog.Error("error drawing polygon", "error", err)
+		return err
+	}
+	slog.Debug("polygon overlaid on the image", "points", cmd.Points, "colour", cmd.Colour)
+	return nil
+}
@@ -0,0 +1,87 @@
+package line
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Polyline is the command that adds a line through a sequence of points as an overlay to an image.
+type Polyline struct {
+	base.InputCommand
+	base.OutputCommand
+	// Points are the points the line goes through.
+	Points []base.Point `short:"p" long:"point" description:"The coordinates of a point the line goes through, as an (x,y) point; repeat it for each point, in order" required:"true"`
+	// Colour is the colour of the polyline to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the polyline to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the polyline should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the polyline should be filled with the given colour, by default it is not" optional:"true"`
+	// Stroke is the width of the polyline stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the polyline stroke, when fill is false" optional:"true" default:"1"`
+	// LineStyle is the cap, join and dash pattern of the stroke.
+	base.LineStyle
+	// Arrow selects the ends of the polyline that carry an arrowhead.
+	Arrow string `short:"a" long:"arrow" description:"The ends of the polyline that carry an arrowhead" optional:"true" choice:"none" choice:"start" choice:"end" choice:"both" default:"none"`
+	// ArrowSize is the length of the arrowheads.
+	ArrowSize base.Length `long:"arrow-size" description:"The length of the arrowheads; by default, four times the width of the stroke, but at least 10 pixels" optional:"true" default:"0"`
+}
+
+// Execute is the real implementation of the Polyline command.
+func (cmd *Polyline) Execute(args []string) error {
+	slog.Debug("running polyline command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the polyline over the given image.
+func (cmd *Polyline) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the polyline on the given canvas.
+func (cmd *Polyline) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill,
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	cmd.Configure(&style, canvas.Width(), canvas.Height(), dpi)
+	points := resolve(cmd.Points, canvas.Width(), canvas.Height(), dpi)
+	size := arrowSize(cmd.ArrowSize, cmd.Stroke, canvas, dpi)
+	if err := pipeline.Polyline(points, pipeline.Arrows(cmd.Arrow), size, style)(canvas); err != nil {
+		slog.Error("error drawing polyline", "error", err)
+		return err
+	}
+	slog.Debug("polyline overlaid on the image", "points", cmd.Points, "arrow", cmd.Arrow, "colour", cmd.Colour)
+	return nil
+}
@@ -0,0 +1,124 @@
+package path
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Path is the command that adds a shape described by SVG path data as an
+// overlay to an image.
+type Path struct {
+	base.InputCommand
+	base.OutputCommand
+	// Data is the SVG path data describing the shape.
+	Data string `long:"d" description:"The SVG path data describing the shape, as in the d attribute of a <path> element (e.g. \"M10 10 L90 10 L50 80 Z\")" required:"true"`
+	// Scale is the scale factor applied to the coordinates of the path.
+	Scale base.Scale `long:"scale" description:"The scale factor applied to the path, either as a single number or as an (x,y) pair" optional:"true" default:"1"`
+	// Rotate is the rotation of the path around the origin of its coordinates.
+	Rotate float64 `long:"rotate" description:"The rotation of the path in degrees, clockwise, around the origin of its coordinates; it is applied after scaling" optional:"true" default:"0"`
+	// Translate is the position in the image of the origin of the path.
+	Translate base.Size `long:"translate" description:"The position in the image of the origin of the path coordinates, as an (x,y) pair; it is applied after scaling and rotating" optional:"true" default:"0,0"`
+	// Placement aligns the bounding box of the path to the underlay image, as an alternative to the translation.
+	base.Placement
+	// Colour is the colour of the path to be written to the image.
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the path to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
+	// Fill is whether the path should be filled with the given colour.
+	Fill bool `short:"f" long:"fill" description:"Whether the path should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the path with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Stroke is the width of the path stroke, when fill is false.
+	Stroke float64 `short:"w" long:"stroke" description:"The width of the path stroke, when fill is false; it is not affected by the scale" optional:"true" default:"1"`
+	// LineStyle is the cap, join and dash pattern of the stroke.
+	base.LineStyle
+}
+
+// Execute is the real implementation of the Path command.
+func (cmd *Path) Execute(args []string) error {
+	slog.Debug("running path command")
+
+	// open the input and output streams
+	underlay, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+	cmd.InheritResolution(cmd.InputResolution())
+
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// Apply paints the path over the given image.
+func (cmd *Path) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the path on the given canvas.
+func (cmd *Path) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	cmd.Configure(&style, canvas.Width(), canvas.Height(), dpi)
+	transform := pipeline.PathTransform{
+		ScaleX: cmd.Scale.X,
+		ScaleY: cmd.Scale.Y,
+		Angle:  cmd.Rotate,
+	}
+	transform.X, transform.Y = cmd.Translate.Resolve(canvas.Width(), canvas.Height(), dpi)
+	// the bounding box of the transformed path is moved where the anchor
+	// places it
+	if cmd.IsAnchored() {
+		left, top, width, height, err := pipeline.PathBounds(cmd.Data, transform)
+		if err != nil {
+			slog.Error("error measuring path", "error", err)
+			return err
+		}
+		x, y, err := cmd.Align(width, height, canvas.Width(), canvas.Height(), dpi)
+		if err != nil {
+			slog.Error("error aligning path", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		transform.X += x - left
+		transform.Y += y - top
+	}
+	if cmd.IsPatterned() {
+		left, top, width, height, err := pipeline.PathBounds(cmd.Data, transform)
+		if err != nil {
+			slog.Error("error measuring path", "error", err)
+			return err
+		}
+		if style.Pattern, err = cmd.Pattern(left, top, width, height, cmd.Colour, canvas.Width(), canvas.Height(), dpi); err != nil {
+			return err
+		}
+	}
+	if err := pipeline.Path(cmd.Data, transform, style)(canvas); err != nil {
+		slog.Error("error drawing path", "error", err)
+		return err
+	}
+	slog.Debug("path overlaid on the image", "scale", cmd.Scale, "rotate", cmd.Rotate, "translate", cmd.Translate, "colour", cmd.Colour)
+	return nil
+}
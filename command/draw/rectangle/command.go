@@ -1,11 +1,11 @@
 package rectangle
 
 import (
-	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Rectangle is the command that adds a rectangle as an overlay to an image.
@@ -14,16 +14,20 @@ type Rectangle struct {
 	base.OutputCommand
 	// Point is the position in the image where the rectangle will start.
 	Point base.Point `short:"p" long:"point" description:"The coordinates where the rectangle will be written, as an (x,y) point" optional:"true"`
+	// Placement aligns the rectangle to the underlay image, as an alternative to the point.
+	base.Placement
 	// Size is the size of the rectangle to be written to the image.
-	Size base.Point `short:"s" long:"size" description:"The size of the rectangle to be written to the image, as an (width,height) point" optional:"true"`
+	Size base.Size `short:"s" long:"size" description:"The size of the rectangle to be written to the image, as an (width,height) pair" optional:"true"`
 	// Colour is the colour of the rectangle to be written to the image.
-	Colour base.Colour `short:"c" long:"colour" description:"The colour of the rectangle to be written to the image" optional:"true" default:"#000000"`
+	Colour base.Paint `short:"c" long:"colour" description:"The colour of the rectangle to be written to the image, or a gradient such as linear(0,0,100%,0,#FF0000,#0000FF)" optional:"true" default:"#000000"`
 	// Fill is whether the rectangle should be filled with the given colour.
 	Fill bool `short:"f" long:"fill" description:"Whether the rectangle should be filled with the given colour, by default it is not" optional:"true"`
+	// FillPattern fills the rectangle with an image or a pattern, instead of the colour.
+	base.FillPattern
 	// Stroke is the width of the rectangle stroke, when fill is false.
 	Stroke float64 `short:"w" long:"stroke" description:"The width of the rectangle stroke, when fill is false" optional:"true" default:"1"`
 	// Radius defines a rounded rectangle by rounding the corners of the rectangle
-	Radius float64 `short:"r" long:"radius" description:"The radius of the rectangle corners" optional:"true" default:"0"`
+	Radius base.Length `short:"r" long:"radius" description:"The radius of the rectangle corners" optional:"true" default:"0"`
 }
 
 // Execute is the real implementation of the Rectangle command.
@@ -36,42 +40,14 @@ func (cmd *Rectangle) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	// create the device context with the underlay image
-	dc := gg.NewContextForImage(underlay)
-	defer dc.Close()
-
-	// set the colour
-	dc.SetRGBA(float64(cmd.Colour.R), float64(cmd.Colour.G), float64(cmd.Colour.B), float64(cmd.Colour.A))
-
-	if cmd.Radius > 0 {
-		slog.Debug("drawing rounded rectangle", "point", cmd.Point, "size", cmd.Size, "radius", cmd.Radius)
-		// define the rounded rectangle area: (x1, y1) to (x2, y2)
-		// rounded rectangle is defined by the top-left corner and the size
-		dc.DrawRoundedRectangle(cmd.Point.X, cmd.Point.Y, cmd.Size.X, cmd.Size.Y, cmd.Radius)
-	} else {
-		slog.Debug("drawing rectangle", "point", cmd.Point, "size", cmd.Size)
-		// define the rectangle area: (x1, y1) to (x2, y2)
-		// rectangle is defined by the top-left corner and the size
-		dc.DrawRectangle(cmd.Point.X, cmd.Point.Y, cmd.Size.X, cmd.Size.Y)
-	}
-
-	if cmd.Fill {
-		slog.Debug("drawing rectangle as fill", "colour", cmd.Colour)
-		dc.Fill()
-	} else if cmd.Stroke > 0 {
-		slog.Debug("drawing rectangle as stroke", "width", cmd.Stroke)
-		dc.SetLineWidth(cmd.Stroke)
-		dc.Stroke()
-	} else {
-		slog.Error("either --fill or --stroke must be specified")
-		return fmt.Errorf("either --fill or --stroke must be specified")
+	img, err := cmd.Apply(underlay)
+	if err != nil {
+		return err
 	}
 
-	slog.Debug("rectangle overlaid on the image", "point", cmd.Point, "size", cmd.Size, "colour", cmd.Colour)
-
 	// write the image to the output stream
-	img := dc.Image()
 	if err := cmd.WriteOutput(img); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -80,3 +56,46 @@ func (cmd *Rectangle) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply paints the rectangle over the given image.
+func (cmd *Rectangle) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint draws the rectangle on the given canvas.
+func (cmd *Rectangle) Paint(canvas *pipeline.Canvas) error {
+	style := pipeline.Style{
+		Colour:   cmd.Colour,
+		Gradient: cmd.Colour.Gradient(canvas.Width(), canvas.Height(), cmd.OutputResolution()),
+		Fill:     cmd.Fill || cmd.IsPatterned(),
+		Stroke:   cmd.Stroke,
+	}
+	dpi := cmd.OutputResolution()
+	x, y := cmd.Point.Resolve(canvas.Width(), canvas.Height(), dpi)
+	width, height := cmd.Size.Resolve(canvas.Width(), canvas.Height(), dpi)
+	if cmd.IsAnchored() {
+		var err error
+		if x, y, err = cmd.Align(width, height, canvas.Width(), canvas.Height(), dpi); err != nil {
+			slog.Error("error aligning rectangle", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+	}
+	radius := cmd.Radius.ResolveIn(canvas.Width(), canvas.Height(), dpi)
+	pattern, err := cmd.Pattern(x, y, width, height, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	if err != nil {
+		return err
+	}
+	style.Pattern = pattern
+	if err := pipeline.Rectangle(x, y, width, height, radius, style)(canvas); err != nil {
+		slog.Error("error drawing rectangle", "error", err)
+		return err
+	}
+	slog.Debug("rectangle overlaid on the image", "point", cmd.Point, "size", cmd.Size, "colour", cmd.Colour)
+	return nil
+}
@@ -0,0 +1,218 @@
+package scene
+
+import (
+	"errors"
+	"fmt"
+	goimage "image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/command/draw/arc"
+	"github.com/dihedron/overlay/command/draw/canvas"
+	"github.com/dihedron/overlay/command/draw/circle"
+	"github.com/dihedron/overlay/command/draw/ellipse"
+	"github.com/dihedron/overlay/command/draw/image"
+	"github.com/dihedron/overlay/command/draw/line"
+	"github.com/dihedron/overlay/command/draw/path"
+	"github.com/dihedron/overlay/command/draw/rectangle"
+	"github.com/dihedron/overlay/command/draw/text"
+	"github.com/dihedron/overlay/pipeline"
+	"github.com/jessevdk/go-flags"
+)
+
+// Scene is the command that renders a whole composition, described by a
+// YAML or JSON document, in a single pass.
+type Scene struct {
+	base.InputCommand
+	base.OutputCommand
+	// Scene is the name of the file describing the composition.
+	Scene flags.Filename `short:"s" long:"scene" description:"The name of the YAML or JSON file describing the scene" required:"true"`
+}
+
+// layer is implemented by all commands that can be used as scene layers.
+type layer interface {
+	Paint(canvas *pipeline.Canvas) error
+}
+
+// layers maps the type of each scene layer to a factory for the command
+// that paints it; the type is the same as the name of the command.
+var layers = map[string]func() layer{
+	"text":              func() layer { return &text.Text{} },
+	"rectangle":         func() layer { return &rectangle.Rectangle{} },
+	"circle":            func() layer { return &circle.Circle{} },
+	"ellipse":           func() layer { return &ellipse.Ellipse{} },
+	"circular-arc":      func() layer { return &arc.CircularArc{} },
+	"elliptical-arc":    func() layer { return &arc.EllipticalArc{} },
+	"circular-sector":   func() layer { return &arc.CircularSector{} },
+	"elliptical-sector": func() layer { return &arc.EllipticalSector{} },
+	"circular-ring":     func() layer { return &arc.CircularRing{} },
+	"elliptical-ring":   func() layer { return &arc.EllipticalRing{} },
+	"line":              func() layer { return &line.Line{} },
+	"polyline":          func() layer { return &line.Polyline{} },
+	"polygon":           func() layer { return &line.Polygon{} },
+	"path":              func() layer { return &path.Path{} },
+	"image":             func() layer { return &image.Image{} },
+}
+
+// Execute is the real implementation of the Scene command.
+func (cmd *Scene) Execute(args []string) error {
+	slog.Debug("running scene command", "scene", cmd.Scene)
+
+	document, err := Load(string(cmd.Scene))
+	if err != nil {
+		slog.Error("error loading scene document", "name", cmd.Scene, "error", err)
+		return err
+	}
+
+	// encoding options in the document override those on the command line,
+	// which are kept for those the document does not mention
+	if len(document.Encoding) > 0 {
+		options, err := rebind(document.Encoding, cmd.EncodingOptions)
+		if err != nil {
+			slog.Error("invalid scene encoding options", "name", cmd.Scene, "error", err)
+			return fmt.Errorf("encoding: %w", err)
+		}
+		cmd.EncodingOptions = options
+	}
+
+	// the input stream is only needed if the scene has no base
+	var underlay goimage.Image
+	if len(document.Base) == 0 {
+		slog.Debug("scene has no base, reading input stream", "name", cmd.Input)
+		if underlay, err = cmd.ReadInput(); err != nil {
+			slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+			return err
+		}
+		cmd.InheritResolution(cmd.InputResolution())
+	}
+
+	img, err := cmd.render(document, underlay)
+	if err != nil {
+		return err
+	}
+
+	// write the image to the output stream
+	if err := cmd.WriteOutput(img); err != nil {
+		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
+		return err
+	}
+	slog.Debug("image correctly encoded", "filename", cmd.Output, "format", cmd.Format)
+
+	return nil
+}
+
+// NeedsInput returns whether the scene is painted over the input image,
+// i.e. whether its document has no base; if the document cannot be read, the
+// input is not needed, since rendering fails anyway.
+func (cmd *Scene) NeedsInput() bool {
+	document, err := Load(string(cmd.Scene))
+	if err != nil {
+		return false
+	}
+	return len(document.Base) == 0
+}
+
+// Apply renders the scene; the given image is used as the base only if the
+// scene document does not specify one.
+func (cmd *Scene) Apply(underlay goimage.Image) (goimage.Image, error) {
+	document, err := Load(string(cmd.Scene))
+	if err != nil {
+		slog.Error("error loading scene document", "name", cmd.Scene, "error", err)
+		return nil, err
+	}
+	return cmd.render(document, underlay)
+}
+
+// render paints all the layers of the scene document over its base.
+func (cmd *Scene) render(document *Document, underlay goimage.Image) (goimage.Image, error) {
+	// prepare the base canvas
+	canvas, dpi, source, err := newCanvas(document.Base, underlay, cmd.InputSource())
+	if err != nil {
+		slog.Error("error preparing scene base", "name", cmd.Scene, "error", err)
+		return nil, err
+	}
+	defer canvas.Close()
+	cmd.InheritResolution(dpi)
+
+	// bind each layer to its command and collect the painters
+	painters := []pipeline.Painter{}
+	for i, values := range document.Layers {
+		kind, ok := values["type"].(string)
+		if !ok {
+			slog.Error("scene layer has no type", "index", i)
+			return nil, fmt.Errorf("layer %d: missing type", i)
+		}
+		factory, ok := layers[kind]
+		if !ok {
+			slog.Error("unsupported scene layer type", "index", i, "type", kind)
+			return nil, fmt.Errorf("layer %d: unsupported type %q", i, kind)
+		}
+
+		options := make(map[string]any, len(values))
+		for key, value := range values {
+			if key != "type" {
+				options[key] = value
+			}
+		}
+		for _, key := range sceneOnly {
+			if _, ok := options[key]; ok {
+				slog.Error("scene layer has a key of the scene", "index", i, "type", kind, "key", key)
+				return nil, fmt.Errorf("layer %d (%s): %q can only be given to the scene, not to its layers", i, kind, key)
+			}
+		}
+		l := factory()
+		if err := bind(options, l); err != nil {
+			slog.Error("invalid scene layer", "index", i, "type", kind, "error", err)
+			return nil, fmt.Errorf("layer %d (%s): %w", i, kind, err)
+		}
+		if r, ok := l.(base.ResolutionInheritor); ok {
+			r.InheritResolution(cmd.OutputResolution())
+		}
+		if s, ok := l.(base.SourceInheritor); ok {
+			s.InheritSource(source)
+		}
+		slog.Debug("scene layer bound", "index", i, "type", kind)
+		painters = append(painters, l.Paint)
+	}
+
+	// render all layers
+	if _, err := canvas.Apply(painters...); err != nil {
+		slog.Error("error rendering scene", "name", cmd.Scene, "error", err)
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// newCanvas creates the canvas described by the base section of the
+// scene document, or one backed by the given image, described by the given
+// source, if there is none; it also returns the resolution of the base image,
+// in DPI, if known, and its description.
+func newCanvas(values map[string]any, underlay goimage.Image, source base.Source) (*pipeline.Canvas, float64, base.Source, error) {
+	if len(values) == 0 {
+		if underlay == nil {
+			return nil, 0, base.Source{}, errors.New("no base image")
+		}
+		return pipeline.NewCanvasFromImage(underlay), 0, source, nil
+	}
+
+	if _, ok := values["input"]; ok {
+		input := &base.InputCommand{}
+		if err := bind(values, input); err != nil {
+			return nil, 0, base.Source{}, fmt.Errorf("base: %w", err)
+		}
+		slog.Debug("scene base is an image", "name", input.Input)
+		underlay, err := input.ReadInput()
+		if err != nil {
+			return nil, 0, base.Source{}, err
+		}
+		return pipeline.NewCanvasFromImage(underlay), input.InputResolution(), input.InputSource(), nil
+	}
+
+	c := &canvas.Canvas{}
+	if err := bind(values, c); err != nil {
+		return nil, 0, base.Source{}, fmt.Errorf("base: %w", err)
+	}
+	slog.Debug("scene base is a canvas", "size", c.Size, "colour", c.Colour)
+	canvas, err := c.NewCanvas()
+	return canvas, c.DPI, base.Source{}, err
+}
@@ -0,0 +1,133 @@
+package scene
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the description of a whole composition: a base image (either
+// an existing image or a new canvas) and an ordered list of layers to paint
+// over it. Since JSON is a subset of YAML, documents can be written in either
+// format.
+//
+// The keys of the base and of each layer are the long names of the flags
+// accepted by the corresponding command (e.g. "point", "colour", "fill"), and
+// their values are given in the same format as on the command line; the type
+// of each layer is given by its "type" key, e.g.:
+//
+//	base:
+//	  size: 640,480
+//	  colour: "#FFFFFF"
+//	layers:
+//	  - type: rectangle
+//	    point: 10,10
+//	    size: 100,50
+//	    colour: "#FF0000"
+//	    fill: true
+type Document struct {
+	// Base describes the image the layers are painted on: it can either have
+	// an "input" key, holding the path to an existing image, or the "size" and
+	// "colour" keys of a new canvas; if omitted, the image is read from the
+	// input of the scene command.
+	Base map[string]any `yaml:"base"`
+	// Layers is the ordered list of elements to paint over the base image.
+	Layers []map[string]any `yaml:"layers"`
+	// Encoding holds the options used to encode the rendered scene (e.g.
+	// "jpeg-quality" or "png-compression"); when present, they override
+	// those given on the command line, while the options they do not mention
+	// keep their command-line or default values.
+	Encoding map[string]any `yaml:"encoding"`
+}
+
+// Load reads and parses a scene document from the given file.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	document := &Document{}
+	if err := yaml.Unmarshal(data, document); err != nil {
+		return nil, fmt.Errorf("invalid scene document %s: %w", path, err)
+	}
+	return document, nil
+}
+
+// sceneOnly are the keys that layers get from their input and output options,
+// but that only the scene honours, since it reads the base image and writes
+// the result on behalf of all layers.
+var sceneOnly = []string{
+	"input",
+	"output",
+	"format",
+	"jpeg-quality",
+	"png-compression",
+	"gif-colours",
+	"gif-quantizer",
+	"gif-dither",
+	"tiff-compression",
+}
+
+// bind parses a set of scene document keys into the flags of the given
+// command, exactly as if they had been given on the command line; defaults
+// are applied to all flags that are not specified.
+func bind(values map[string]any, command any) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, key := range keys {
+		switch value := values[key].(type) {
+		case nil:
+			args = append(args, "--"+key)
+		case bool:
+			if value {
+				args = append(args, "--"+key)
+			}
+		case []any:
+			for _, v := range value {
+				args = append(args, fmt.Sprintf("--%s=%v", key, v))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", key, value))
+		}
+	}
+
+	parser := flags.NewParser(command, flags.None)
+	remaining, err := parser.ParseArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return fmt.Errorf("unexpected values: %v", remaining)
+	}
+	return nil
+}
+
+// rebind parses a set of scene document keys into a copy of the given flags,
+// so that the flags not specified keep their current values rather than being
+// reset to their defaults.
+func rebind[T any](values map[string]any, current T) (T, error) {
+	merged := map[string]any{}
+	v := reflect.ValueOf(current)
+	for i := 0; i < v.NumField(); i++ {
+		if name := v.Type().Field(i).Tag.Get("long"); name != "" {
+			merged[name] = v.Field(i).Interface()
+		}
+	}
+	maps.Copy(merged, values)
+
+	var options T
+	if err := bind(merged, &options); err != nil {
+		return options, err
+	}
+	return options, nil
+}
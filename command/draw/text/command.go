@@ -1,28 +1,55 @@
 package text
 
 import (
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
-	"github.com/gogpu/gg"
-	"github.com/gogpu/gg/text"
-	"github.com/jessevdk/go-flags"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Text is the command that adds text as an overlay to an image.
 type Text struct {
 	base.InputCommand
 	base.OutputCommand
-	// Text is the text to write as an overlay to the image.
-	Text string `short:"t" long:"text" description:"The text to add as an overlay to the given image" optional:"true"`
+	// TextLayout holds the text and how it is shaped and laid out.
+	base.TextLayout
 	// Point is the position in the image where the text will start.
 	Point base.Point `short:"p" long:"point" description:"The coordinates where the text will be written, as an (x,y) point" optional:"true"`
-	// Font is the font to use for writing to the image.
-	Font flags.Filename `short:"f" long:"font" description:"The name of the font to be used for writing" optional:"true"`
+	// Placement aligns the text to the underlay image, as an alternative to the point.
+	base.Placement
 	// Colour is the colour of the font to be used for writing to the image.
 	Colour base.Colour `short:"c" long:"colour" description:"The colour of the font to be used for writing" optional:"true" default:"#000000"`
-	// Size is the size of font to use for writing to the image.
-	Size float64 `short:"s" long:"size" description:"The size of the font to be used for writing" optional:"true" default:"12"`
+	// FillPattern fills the glyphs with an image or a pattern, instead of the colour.
+	base.FillPattern
+	// Box is the box the text is fitted into, as an alternative to the size.
+	Box base.Box `long:"box" description:"The box the text is fitted into, as an (x,y,width,height) tuple; the largest font size at which the wrapped text fits is used instead of the size" optional:"true"`
+	// MinSize is the minimum font size used when fitting the text into the box.
+	MinSize float64 `long:"min-size" description:"The minimum size of the font when fitting the text into the box" optional:"true" default:"6"`
+	// MaxSize is the maximum font size used when fitting the text into the box.
+	MaxSize float64 `long:"max-size" description:"The maximum size of the font when fitting the text into the box" optional:"true" default:"200"`
+	// Angle is the rotation of the text around its anchor.
+	Angle float64 `long:"angle" description:"The rotation of the text in degrees, clockwise, around its anchor or its point" optional:"true" default:"0"`
+	// ArcRadius is the radius of the circle the text is laid along.
+	ArcRadius base.Length `long:"arc-radius" description:"The radius of the circle, centred at the point, the text is laid along; by default, the text is laid straight" optional:"true"`
+	// ArcAngle is the arc of the circle the text is laid along.
+	ArcAngle base.Pair `long:"arc-angle" description:"The arc of the circle the text is laid along, as (start,end) angles in degrees; the text runs clockwise if the end is greater than the start, anticlockwise otherwise" optional:"true" default:"180,360"`
+	// OutlineColour is the colour of the stroke around the glyphs.
+	OutlineColour base.Colour `long:"outline-colour" description:"The colour of the outline around the glyphs; by default, there is no outline" optional:"true"`
+	// OutlineWidth is the width of the stroke around the glyphs.
+	OutlineWidth base.Length `long:"outline-width" description:"The width of the outline around the glyphs" optional:"true" default:"2"`
+	// ShadowColour is the colour of the shadow cast by the text.
+	ShadowColour base.Colour `long:"shadow-colour" description:"The colour of the shadow cast by the text; by default, there is no shadow" optional:"true"`
+	// ShadowOffset is the distance of the shadow from the text.
+	ShadowOffset base.Size `long:"shadow-offset" description:"The distance of the shadow from the text, as an (x,y) pair" optional:"true" default:"2,2"`
+	// ShadowBlur is the radius of the blur applied to the shadow.
+	ShadowBlur base.Length `long:"shadow-blur" description:"The radius of the blur applied to the shadow" optional:"true" default:"0"`
+	// BackgroundColour is the colour of the plate behind the text.
+	BackgroundColour base.Colour `long:"background-colour" description:"The colour of the plate behind the text; by default, there is no plate" optional:"true"`
+	// BackgroundPadding is the space between the text and the edges of the plate.
+	BackgroundPadding base.Length `long:"background-padding" description:"The space between the text and the edges of the plate behind it" optional:"true" default:"0"`
+	// BackgroundRadius is the radius of the corners of the plate.
+	BackgroundRadius base.Length `long:"background-radius" description:"The radius of the corners of the plate behind the text" optional:"true" default:"0"`
 }
 
 // Execute is the real implementation of the Text command.
@@ -34,27 +61,15 @@ func (cmd *Text) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 	slog.Debug("underlay image decoded", "name", cmd.Input, "width", underlay.Bounds().Dx(), "height", underlay.Bounds().Dy())
 
-	dc := gg.NewContextForImage(underlay)
-	defer dc.Close()
-
-	// load font
-	source, err := text.NewFontSourceFromFile(string(cmd.Font))
+	img, err := cmd.Apply(underlay)
 	if err != nil {
-		slog.Error("error loading font file", "name", cmd.Font, "error", err)
 		return err
 	}
-	defer source.Close()
-
-	// render text
-	slog.Debug("overlaying text on the image", "text", cmd.Text, "point", cmd.Point, "size", cmd.Size, "font", cmd.Font)
-	dc.SetFont(source.Face(cmd.Size))
-	dc.SetRGBA(float64(cmd.Colour.R), float64(cmd.Colour.G), float64(cmd.Colour.B), float64(cmd.Colour.A))
-	dc.DrawString(cmd.Text, cmd.Point.X, cmd.Point.Y)
 
 	// write to output
-	img := dc.Image()
 	err = cmd.WriteOutput(img)
 	if err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
@@ -64,3 +79,154 @@ func (cmd *Text) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply paints the text over the given image.
+func (cmd *Text) Apply(underlay image.Image) (image.Image, error) {
+	canvas := pipeline.NewCanvasFromImage(underlay)
+	defer canvas.Close()
+
+	if _, err := canvas.Apply(cmd.Paint); err != nil {
+		return nil, err
+	}
+	return canvas.Image(), nil
+}
+
+// Paint writes the text on the given canvas.
+func (cmd *Text) Paint(canvas *pipeline.Canvas) error {
+	content, err := cmd.Content(cmd.InputSource(), canvas.Width(), canvas.Height())
+	if err != nil {
+		return err
+	}
+
+	// render text
+	slog.Debug("overlaying text on the image", "text", content, "point", cmd.Point, "size", cmd.Size, "font", cmd.Font)
+	dpi := cmd.OutputResolution()
+	width, height := canvas.Width(), canvas.Height()
+	style, err := cmd.Style(width, height, dpi)
+	if err != nil {
+		return err
+	}
+	style.Colour = cmd.Colour
+	style.OutlineColour = cmd.OutlineColour.Optional()
+	style.OutlineWidth = cmd.OutlineWidth.ResolveIn(width, height, dpi)
+	style.ShadowColour = cmd.ShadowColour.Optional()
+	style.ShadowOffsetX, style.ShadowOffsetY = cmd.ShadowOffset.Resolve(width, height, dpi)
+	style.ShadowBlur = cmd.ShadowBlur.ResolveIn(width, height, dpi)
+	style.BackgroundColour = cmd.BackgroundColour.Optional()
+	style.BackgroundPadding = cmd.BackgroundPadding.ResolveIn(width, height, dpi)
+	style.BackgroundRadius = cmd.BackgroundRadius.ResolveIn(width, height, dpi)
+
+	// position the text and find the point it is rotated around
+	x, y := cmd.Point.Resolve(width, height, dpi)
+	pivotX, pivotY := x, y
+	radius := 0.0
+	switch {
+	case cmd.ArcRadius.Value > 0:
+		// the centre of the circle is either the point or that of its
+		// bounding box, aligned to the anchor
+		radius = cmd.ArcRadius.ResolveIn(width, height, dpi)
+		if cmd.IsAnchored() {
+			if x, y, err = cmd.Align(2*radius, 2*radius, width, height, dpi); err != nil {
+				slog.Error("error aligning text", "anchor", cmd.Anchor, "error", err)
+				return err
+			}
+			x, y = x+radius, y+radius
+		}
+		pivotX, pivotY = x, y
+	case !cmd.Box.IsZero():
+		if x, y, pivotX, pivotY, err = cmd.fit(content, &style, canvas, dpi); err != nil {
+			return err
+		}
+	case cmd.IsAnchored():
+		// align the measured extents of the text (including the background
+		// plate, if any), then move to the baseline
+		extents, err := pipeline.MeasureText(content, style)
+		if err != nil {
+			slog.Error("error measuring text", "error", err)
+			return err
+		}
+		padding := 0.0
+		if style.BackgroundColour != nil {
+			padding = style.BackgroundPadding
+		}
+		boxWidth, boxHeight := extents.Width+2*padding, extents.Height()+2*padding
+		if x, y, err = cmd.Align(boxWidth, boxHeight, width, height, dpi); err != nil {
+			slog.Error("error aligning text", "anchor", cmd.Anchor, "error", err)
+			return err
+		}
+		ax, ay, _ := pipeline.Anchor(cmd.Anchor).Point(boxWidth, boxHeight)
+		pivotX, pivotY = x+ax, y+ay
+		x, y = x+padding, y+padding+extents.Ascent
+	}
+	if cmd.IsPatterned() {
+		if style.Pattern, err = cmd.pattern(content, style, x, y, radius, canvas, dpi); err != nil {
+			return err
+		}
+	}
+	painter := pipeline.Text(content, x, y, style)
+	if radius > 0 {
+		painter = pipeline.TextOnArc(content, x, y, radius, cmd.ArcAngle.X, cmd.ArcAngle.Y, style)
+	}
+	if cmd.Angle != 0 {
+		painter = pipeline.Rotated(cmd.Angle, pivotX, pivotY, painter)
+	}
+
+	if err := painter(canvas); err != nil {
+		slog.Error("error drawing text", "error", err)
+		return err
+	}
+	return nil
+}
+
+// pattern returns the pattern filling the text, laid out in the bounding box
+// of the text with the first baseline starting at (x, y) or, if the radius
+// is positive, in that of the circle centred at (x, y) the text is laid
+// along.
+func (cmd *Text) pattern(content string, style pipeline.TextStyle, x, y, radius float64, canvas *pipeline.Canvas, dpi float64) (*pipeline.Pattern, error) {
+	if radius > 0 {
+		return cmd.Pattern(x-radius, y-radius, 2*radius, 2*radius, cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+	}
+	extents, err := pipeline.MeasureText(content, style)
+	if err != nil {
+		slog.Error("error measuring text", "error", err)
+		return nil, err
+	}
+	return cmd.Pattern(x, y-extents.Ascent, extents.Width, extents.Height(), cmd.Colour, canvas.Width(), canvas.Height(), dpi)
+}
+
+// fit sets the largest font size at which the text fits into the box and
+// returns the position of its baseline, along with the point it is rotated
+// around; the text is wrapped to the width of the box and, if an anchor is
+// given, aligned within the box.
+func (cmd *Text) fit(content string, style *pipeline.TextStyle, canvas *pipeline.Canvas, dpi float64) (float64, float64, float64, float64, error) {
+	x, y, width, height := cmd.Box.Resolve(canvas.Width(), canvas.Height(), dpi)
+	size, err := pipeline.FitText(content, *style, width, height, cmd.MinSize, cmd.MaxSize)
+	if err != nil {
+		slog.Error("error fitting text into box", "box", cmd.Box, "min", cmd.MinSize, "max", cmd.MaxSize, "error", err)
+		return 0, 0, 0, 0, err
+	}
+	slog.Debug("text fitted into box", "box", cmd.Box, "size", size)
+	style.Size = size
+	style.Width = width
+
+	extents, err := pipeline.MeasureText(content, *style)
+	if err != nil {
+		slog.Error("error measuring text", "error", err)
+		return 0, 0, 0, 0, err
+	}
+	if cmd.IsAnchored() {
+		dx, dy := cmd.Offset.Resolve(canvas.Width(), canvas.Height(), dpi)
+		ax, ay, err := pipeline.Align(pipeline.Anchor(cmd.Anchor), extents.Width, extents.Height(), width, height, dx, dy)
+		if err != nil {
+			slog.Error("error aligning text", "anchor", cmd.Anchor, "error", err)
+			return 0, 0, 0, 0, err
+		}
+		x, y = x+ax, y+ay
+	}
+	anchor := pipeline.NorthWest
+	if cmd.IsAnchored() {
+		anchor = pipeline.Anchor(cmd.Anchor)
+	}
+	px, py, _ := anchor.Point(extents.Width, extents.Height())
+	return x, y + extents.Ascent, x + px, y + py, nil
+}
@@ -1,9 +1,12 @@
 package info
 
 import (
+	"github.com/dihedron/overlay/command/info/fonts"
 	"github.com/dihedron/overlay/command/info/height"
+	"github.com/dihedron/overlay/command/info/resolution"
 	"github.com/dihedron/overlay/command/info/sample"
 	"github.com/dihedron/overlay/command/info/size"
+	"github.com/dihedron/overlay/command/info/text"
 	"github.com/dihedron/overlay/command/info/width"
 )
 
@@ -17,4 +20,10 @@ type Commands struct {
 	Size size.Size `command:"size" alias:"s" description:"Get the size of an image."`
 	// Sample gets the color of a pixel in an image.
 	Sample sample.Sample `command:"sample" alias:"p" description:"Get the color of a pixel in an image."`
+	// Fonts lists the fonts available by family name.
+	Fonts fonts.Fonts `command:"fonts" alias:"f" description:"List the font families that can be used by name in draw text."`
+	// Text measures text as it would be drawn.
+	Text text.Text `command:"text" alias:"t" description:"Get the size of text as it would be drawn by draw text."`
+	// Resolution gets the resolution of an image.
+	Resolution resolution.Resolution `command:"resolution" alias:"r" description:"Get the resolution of an image, in DPI."`
 }
@@ -0,0 +1,37 @@
+package fonts
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Fonts is the command that lists the fonts available by family name, i.e.
+// those found in the font directories and the built-in ones.
+type Fonts struct {
+	// Verbose is the flag that indicates whether to list each font, with its style and file.
+	Verbose bool `short:"v" long:"verbose" description:"List each font of the families, with its style and the file it is stored in"`
+}
+
+// Execute is the implementation of the fonts command.
+func (cmd *Fonts) Execute(args []string) error {
+	slog.Debug("running fonts command")
+
+	previous := ""
+	for _, info := range pipeline.Fonts() {
+		switch {
+		case cmd.Verbose:
+			path := info.Path
+			if path == "" {
+				path = "(built-in)"
+			}
+			fmt.Printf("%s\t%s\n", info.Name(), path)
+		case info.Family != previous:
+			fmt.Println(info.Family)
+		}
+		previous = info.Family
+	}
+	slog.Debug("command done")
+	return nil
+}
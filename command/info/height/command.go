@@ -2,6 +2,7 @@ package height
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
@@ -9,6 +10,8 @@ import (
 
 type Height struct {
 	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
 }
 
 // Execute is the implementation of the height command.
@@ -21,7 +24,13 @@ func (cmd *Height) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Printf("%d", img.Bounds().Dy())
+	_, err = cmd.Apply(img)
+	return err
+}
+
+// Apply prints the height of the given image; the image is returned unchanged.
+func (cmd *Height) Apply(img image.Image) (image.Image, error) {
+	fmt.Fprintf(cmd.Reporter(), "%d", img.Bounds().Dy())
 
-	return nil
+	return img, nil
 }
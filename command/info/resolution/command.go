@@ -0,0 +1,44 @@
+package resolution
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+)
+
+type Resolution struct {
+	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
+}
+
+// Execute is the implementation of the resolution command.
+func (cmd *Resolution) Execute(args []string) error {
+	slog.Debug("running resolution command")
+
+	img, err := cmd.ReadInput()
+	if err != nil {
+		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+		return err
+	}
+
+	_, err = cmd.Apply(img)
+	return err
+}
+
+// Apply prints the resolution of the given image, in DPI, as recorded in the
+// metadata of the input file, whether read by this command or inherited from a
+// chain; the image is returned unchanged.
+func (cmd *Resolution) Apply(img image.Image) (image.Image, error) {
+	dpi := cmd.InputResolution()
+	if dpi <= 0 {
+		slog.Error("image has no resolution information", "name", cmd.Input)
+		return nil, errors.New("image has no resolution information")
+	}
+	fmt.Fprintf(cmd.Reporter(), "%g", dpi)
+
+	return img, nil
+}
@@ -2,6 +2,7 @@ package sample
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
@@ -9,8 +10,12 @@ import (
 
 type Sample struct {
 	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
 	// Point is the point to sample at.
-	Point base.Size `short:"p" long:"point" description:"The coordinates where the pixel will be sampled, as an (x,y) point" required:"true"`
+	Point base.Point `short:"p" long:"point" description:"The coordinates where the pixel will be sampled, as an (x,y) point" required:"true"`
+	// dpi is the resolution of the image.
+	dpi float64
 }
 
 // Execute is the implementation of the sample command.
@@ -22,13 +27,32 @@ func (cmd *Sample) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	r, g, b, a := img.At(cmd.Point.X, cmd.Point.Y).RGBA()
+	_, err = cmd.Apply(img)
+	return err
+}
+
+// InheritResolution records the resolution of the image, in DPI, which is
+// used to convert physical units.
+func (cmd *Sample) InheritResolution(dpi float64) {
+	cmd.dpi = dpi
+}
+
+// Apply prints the colour of the pixel at the given point; the image is returned unchanged.
+func (cmd *Sample) Apply(img image.Image) (image.Image, error) {
+	dpi := cmd.dpi
+	if dpi <= 0 {
+		dpi = base.DefaultDPI
+	}
+	bounds := img.Bounds()
+	x, y := cmd.Point.Resolve(bounds.Dx(), bounds.Dy(), dpi)
+	r, g, b, a := img.At(bounds.Min.X+int(x), bounds.Min.Y+int(y)).RGBA()
 	r >>= 8
 	g >>= 8
 	b >>= 8
 	a >>= 8
-	fmt.Printf("#%02X%02X%02X%02X", r, g, b, a)
+	fmt.Fprintf(cmd.Reporter(), "#%02X%02X%02X%02X", r, g, b, a)
 
-	return nil
+	return img, nil
 }
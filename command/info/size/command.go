@@ -2,6 +2,7 @@ package size
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
@@ -9,6 +10,8 @@ import (
 
 type Size struct {
 	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
 }
 
 // Execute is the implementation of the size command.
@@ -21,7 +24,13 @@ func (cmd *Size) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Printf("%dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	_, err = cmd.Apply(img)
+	return err
+}
+
+// Apply prints the size of the given image; the image is returned unchanged.
+func (cmd *Size) Apply(img image.Image) (image.Image, error) {
+	fmt.Fprintf(cmd.Reporter(), "%dx%d", img.Bounds().Dx(), img.Bounds().Dy())
 
-	return nil
+	return img, nil
 }
@@ -0,0 +1,151 @@
+package text
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"log/slog"
+
+	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
+)
+
+// Text is the command that measures text, as it would be laid out by draw
+// text on the input image.
+type Text struct {
+	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
+	// TextLayout holds the text and how it is shaped and laid out.
+	base.TextLayout
+	// Point is the position in the image where the text would start.
+	Point base.Point `short:"p" long:"point" description:"The coordinates where the text would be written, as an (x,y) point, which the bounding box is relative to" optional:"true"`
+	// Placement aligns the text to the underlay image, as an alternative to the point.
+	base.Placement
+	// JSON is whether the measures are printed as JSON.
+	JSON bool `short:"j" long:"json" description:"Print the measures as a JSON object"`
+	// dpi is the resolution of the image.
+	dpi float64
+}
+
+// Measures are the measures of a block of text, as printed by the command.
+type Measures struct {
+	// Width and Height are the size of the text box.
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Ascent and Descent are the distances from the baseline of the first
+	// line to the top and the bottom of the text box.
+	Ascent  float64 `json:"ascent"`
+	Descent float64 `json:"descent"`
+	// Lines is the number of lines.
+	Lines int `json:"lines"`
+	// Baseline is the point where the baseline of the first line starts.
+	Baseline [2]float64 `json:"baseline"`
+	// Box is the bounding box of the text, as (x, y, width, height).
+	Box [4]float64 `json:"box"`
+}
+
+// Execute is the implementation of the text command.
+func (cmd *Text) Execute(args []string) error {
+	slog.Debug("running text command")
+
+	// the input image is only read if the measures depend on it
+	var img image.Image
+	if cmd.needsImage() {
+		var err error
+		if img, err = cmd.ReadInput(); err != nil {
+			slog.Error("error reading input stream", "name", cmd.Input, "error", err)
+			return err
+		}
+		cmd.InheritResolution(cmd.InputResolution())
+	}
+
+	_, err := cmd.Apply(img)
+	return err
+}
+
+// needsImage returns whether the measures depend on the input image: on its
+// size, through percentages, coordinates measured from the right or bottom
+// edge and anchors, on its resolution, through physical units, or on its
+// metadata, through templates.
+func (cmd *Text) needsImage() bool {
+	if cmd.IsAnchored() || cmd.Template || cmd.Point.X.Value < 0 || cmd.Point.Y.Value < 0 {
+		return true
+	}
+	for _, length := range []base.Length{cmd.Point.X, cmd.Point.Y, cmd.Width, cmd.LetterSpacing} {
+		if length.Unit != base.Pixels {
+			return true
+		}
+	}
+	return false
+}
+
+// InheritResolution records the resolution of the image, in DPI, which is
+// used to convert physical units.
+func (cmd *Text) InheritResolution(dpi float64) {
+	cmd.dpi = dpi
+}
+
+// Apply prints the measures of the text, as it would be laid out on the given
+// image, which may be nil if the measures do not depend on it; the image is
+// returned unchanged.
+func (cmd *Text) Apply(img image.Image) (image.Image, error) {
+	dpi := cmd.dpi
+	if dpi <= 0 {
+		dpi = base.DefaultDPI
+	}
+	var width, height int
+	if img != nil {
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	content, err := cmd.Content(cmd.InputSource(), width, height)
+	if err != nil {
+		return nil, err
+	}
+	style, err := cmd.Style(width, height, dpi)
+	if err != nil {
+		return nil, err
+	}
+	extents, err := pipeline.MeasureText(content, style)
+	if err != nil {
+		slog.Error("error measuring text", "error", err)
+		return nil, err
+	}
+	slog.Debug("text measured", "text", content, "width", extents.Width, "height", extents.Height(), "lines", extents.Lines)
+
+	// the text box is placed as draw text would place it: aligned to the
+	// anchor, or with the baseline of its first line starting at the point
+	x, y := cmd.Point.Resolve(width, height, dpi)
+	top := y - extents.Ascent
+	if cmd.IsAnchored() {
+		if x, top, err = cmd.Align(extents.Width, extents.Height(), width, height, dpi); err != nil {
+			slog.Error("error aligning text", "anchor", cmd.Anchor, "error", err)
+			return nil, err
+		}
+		y = top + extents.Ascent
+	}
+	measures := Measures{
+		Width:    extents.Width,
+		Height:   extents.Height(),
+		Ascent:   extents.Ascent,
+		Descent:  extents.Descent,
+		Lines:    extents.Lines,
+		Baseline: [2]float64{x, y},
+		Box:      [4]float64{x, top, extents.Width, extents.Height()},
+	}
+
+	if cmd.JSON {
+		data, err := json.Marshal(measures)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(cmd.Reporter(), "%s\n", data)
+		return img, nil
+	}
+	fmt.Fprintf(cmd.Reporter(), "width: %g\nheight: %g\nascent: %g\ndescent: %g\nlines: %d\nbaseline: %g,%g\nbox: %g,%g,%g,%g\n",
+		measures.Width, measures.Height, measures.Ascent, measures.Descent, measures.Lines,
+		measures.Baseline[0], measures.Baseline[1],
+		measures.Box[0], measures.Box[1], measures.Box[2], measures.Box[3])
+	return img, nil
+}
@@ -2,6 +2,7 @@ package width
 
 import (
 	"fmt"
+	"image"
 	"log/slog"
 
 	"github.com/dihedron/overlay/command/base"
@@ -9,6 +10,8 @@ import (
 
 type Width struct {
 	base.InputCommand
+	// Report is where the information is printed.
+	base.Report
 }
 
 // Execute is the implementation of the width command.
@@ -21,7 +24,13 @@ func (cmd *Width) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Printf("%d", img.Bounds().Dx())
+	_, err = cmd.Apply(img)
+	return err
+}
+
+// Apply prints the width of the given image; the image is returned unchanged.
+func (cmd *Width) Apply(img image.Image) (image.Image, error) {
+	fmt.Fprintf(cmd.Reporter(), "%d", img.Bounds().Dx())
 
-	return nil
+	return img, nil
 }
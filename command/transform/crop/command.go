@@ -4,8 +4,8 @@ import (
 	"image"
 	"log/slog"
 
-	"github.com/anthonynsimon/bild/transform"
 	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Crop crops an image to the given rectangle.
@@ -25,12 +25,12 @@ func (cmd *Crop) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	result := transform.Crop(img, image.Rectangle{
-		Min: image.Point{X: cmd.Rectangle.TopLeft.X, Y: cmd.Rectangle.TopLeft.Y},
-		Max: image.Point{X: cmd.Rectangle.BottomRight.X, Y: cmd.Rectangle.BottomRight.Y},
-	})
-	slog.Debug("image cropped", "rectangle", cmd.Rectangle)
+	result, err := cmd.Apply(img)
+	if err != nil {
+		return err
+	}
 
 	if err := cmd.WriteOutput(result); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
@@ -40,3 +40,12 @@ func (cmd *Crop) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply crops the given image to the rectangle.
+func (cmd *Crop) Apply(img image.Image) (image.Image, error) {
+	rectangle := cmd.Rectangle.Resolve(img.Bounds().Dx(), img.Bounds().Dy(), cmd.OutputResolution())
+	result := pipeline.Crop(rectangle)(img)
+	slog.Debug("image cropped", "rectangle", rectangle)
+
+	return result, nil
+}
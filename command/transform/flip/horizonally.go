@@ -1,10 +1,11 @@
 package flip
 
 import (
+	"image"
 	"log/slog"
 
-	"github.com/anthonynsimon/bild/transform"
 	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Flips an image horizontally.
@@ -22,9 +23,12 @@ func (cmd *FlipHorizontally) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	result := transform.FlipH(img)
-	slog.Debug("image flipped horizontally")
+	result, err := cmd.Apply(img)
+	if err != nil {
+		return err
+	}
 
 	if err := cmd.WriteOutput(result); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
@@ -34,3 +38,11 @@ func (cmd *FlipHorizontally) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply flips the given image horizontally.
+func (cmd *FlipHorizontally) Apply(img image.Image) (image.Image, error) {
+	result := pipeline.FlipHorizontally(img)
+	slog.Debug("image flipped horizontally")
+
+	return result, nil
+}
@@ -3,9 +3,10 @@ package rotate
 import (
 	"image"
 	"log/slog"
+	"math"
 
-	"github.com/anthonynsimon/bild/transform"
 	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Rotate rotates an image.
@@ -15,7 +16,7 @@ type Rotate struct {
 	// Angle is the angle in degrees to rotate the image.
 	Angle float64 `short:"a" long:"angle" description:"The angle in degrees to rotate the image" optional:"true" default:"90"`
 	// Pivot is the point around which the image will be rotated.
-	Pivot base.Size `short:"p" long:"pivot" description:"The point around which the image will be rotated, as an (x,y) point" optional:"true"`
+	Pivot base.Point `short:"p" long:"pivot" description:"The point around which the image will be rotated, as an (x,y) point" optional:"true"`
 	// ResizeBounds determines whether the output image should be resized to fit the rotated image.
 	ResizeBounds bool `short:"r" long:"resize-bounds" description:"Whether the output image should be resized to fit the rotated image" optional:"true"`
 }
@@ -29,15 +30,13 @@ func (cmd *Rotate) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	opts := &transform.RotationOptions{
-		ResizeBounds: cmd.ResizeBounds,
-		Pivot:        &image.Point{X: cmd.Pivot.X, Y: cmd.Pivot.Y},
+	result, err := cmd.Apply(img)
+	if err != nil {
+		return err
 	}
 
-	result := transform.Rotate(img, cmd.Angle, opts)
-	slog.Debug("image rotated", "angle", cmd.Angle, "pivot", cmd.Pivot, "resize", cmd.ResizeBounds)
-
 	if err := cmd.WriteOutput(result); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -46,3 +45,13 @@ func (cmd *Rotate) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply rotates the given image.
+func (cmd *Rotate) Apply(img image.Image) (image.Image, error) {
+	x, y := cmd.Pivot.Resolve(img.Bounds().Dx(), img.Bounds().Dy(), cmd.OutputResolution())
+	pivot := &image.Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+	result := pipeline.Rotate(cmd.Angle, pivot, cmd.ResizeBounds)(img)
+	slog.Debug("image rotated", "angle", cmd.Angle, "pivot", cmd.Pivot, "resize", cmd.ResizeBounds)
+
+	return result, nil
+}
@@ -3,9 +3,10 @@ package zoom
 import (
 	"image"
 	"log/slog"
+	"math"
 
-	"github.com/anthonynsimon/bild/transform"
 	"github.com/dihedron/overlay/command/base"
+	"github.com/dihedron/overlay/pipeline"
 )
 
 // Zoom zooms an image.
@@ -13,7 +14,7 @@ type Zoom struct {
 	base.InputCommand
 	base.OutputCommand
 	// Pivot is the point around which the image will be zoomed.
-	Pivot base.Size `short:"p" long:"pivot" description:"The point around which the image will be zoomed, as an (x,y) point" optional:"true"`
+	Pivot base.Point `short:"p" long:"pivot" description:"The point around which the image will be zoomed, as an (x,y) point" optional:"true"`
 	// Factor determines the factor by which the image will be zoomed (>1.0 zooms in, <1.0 zooms out).
 	Factor float64 `short:"f" long:"factor" description:"The factor by which the image will be zoomed (>1.0 zooms in, <1.0 zooms out)" optional:"true" default:"2.0"`
 }
@@ -27,14 +28,13 @@ func (cmd *Zoom) Execute(args []string) error {
 		slog.Error("error reading input stream", "name", cmd.Input, "error", err)
 		return err
 	}
+	cmd.InheritResolution(cmd.InputResolution())
 
-	opts := &transform.ZoomOptions{
-		Pivot: &image.Point{X: cmd.Pivot.X, Y: cmd.Pivot.Y},
+	result, err := cmd.Apply(img)
+	if err != nil {
+		return err
 	}
 
-	result := transform.Zoom(img, cmd.Factor, opts)
-	slog.Debug("image zoomed", "factor", cmd.Factor, "pivot", cmd.Pivot)
-
 	if err := cmd.WriteOutput(result); err != nil {
 		slog.Error("error writing output stream", "name", cmd.Output, "error", err)
 		return err
@@ -43,3 +43,13 @@ func (cmd *Zoom) Execute(args []string) error {
 
 	return nil
 }
+
+// Apply zooms the given image.
+func (cmd *Zoom) Apply(img image.Image) (image.Image, error) {
+	x, y := cmd.Pivot.Resolve(img.Bounds().Dx(), img.Bounds().Dy(), cmd.OutputResolution())
+	pivot := &image.Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+	result := pipeline.Zoom(cmd.Factor, pivot)(img)
+	slog.Debug("image zoomed", "factor", cmd.Factor, "pivot", cmd.Pivot)
+
+	return result, nil
+}
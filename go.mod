@@ -4,16 +4,17 @@ go 1.26
 
 require (
 	github.com/anthonynsimon/bild v0.15.0
+	github.com/go-text/typesetting v0.3.4
 	github.com/gogpu/gg v0.43.1
 	github.com/jessevdk/go-flags v1.6.1
 	github.com/joho/godotenv v1.5.1
 	golang.org/x/image v0.39.0
+	golang.org/x/text v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/go-text/typesetting v0.3.4 // indirect
 	github.com/gogpu/gpucontext v0.15.0 // indirect
 	github.com/gogpu/gputypes v0.5.0 // indirect
 	golang.org/x/sys v0.43.0 // indirect
-	golang.org/x/text v0.36.0 // indirect
 )
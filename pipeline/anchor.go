@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"fmt"
+)
+
+// Anchor is the point of the canvas an element is aligned to, named after
+// the compass directions (north is the top edge).
+type Anchor string
+
+const (
+	NorthWest Anchor = "north-west"
+	North     Anchor = "north"
+	NorthEast Anchor = "north-east"
+	West      Anchor = "west"
+	Center    Anchor = "center"
+	East      Anchor = "east"
+	SouthWest Anchor = "south-west"
+	South     Anchor = "south"
+	SouthEast Anchor = "south-east"
+)
+
+// anchors maps each anchor to its relative position along the horizontal
+// and vertical axes, from 0 (left or top) to 1 (right or bottom).
+var anchors = map[Anchor][2]float64{
+	NorthWest: {0, 0},
+	North:     {0.5, 0},
+	NorthEast: {1, 0},
+	West:      {0, 0.5},
+	Center:    {0.5, 0.5},
+	East:      {1, 0.5},
+	SouthWest: {0, 1},
+	South:     {0.5, 1},
+	SouthEast: {1, 1},
+}
+
+// Point returns the position of the anchor within a box of the given size,
+// relative to its top-left corner.
+func (a Anchor) Point(width, height float64) (float64, float64, error) {
+	position, ok := anchors[a]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid anchor: %q", a)
+	}
+	return width * position[0], height * position[1], nil
+}
+
+// Align returns the top-left corner of a box of the given size, aligned to
+// the anchor of a container (e.g. the canvas) of the given size; the offset (dx, dy) moves the box
+// away from the edges it is aligned to, i.e. towards the inside of the container,
+// and rightwards or downwards along the axes where the box is centred.
+func Align(anchor Anchor, width, height, containerWidth, containerHeight, dx, dy float64) (float64, float64, error) {
+	position, ok := anchors[anchor]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid anchor: %q", anchor)
+	}
+	if position[0] == 1 {
+		dx = -dx
+	}
+	if position[1] == 1 {
+		dy = -dy
+	}
+	x := (containerWidth-width)*position[0] + dx
+	y := (containerHeight-height)*position[1] + dy
+	return x, y, nil
+}
@@ -0,0 +1,215 @@
+package pipeline
+
+import (
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// levels resolves the embedding level of each character of a paragraph with
+// the given base level (0 for left-to-right, 1 for right-to-left), applying
+// the weak, neutral and implicit rules of the Unicode bidi algorithm (UAX #9);
+// explicit directional formatting characters are treated as neutrals.
+func levels(text []rune, base uint8) []uint8 {
+	classes := make([]bidi.Class, len(text))
+	for i, r := range text {
+		properties, _ := bidi.LookupRune(r)
+		classes[i] = properties.Class()
+	}
+	sos := bidi.L
+	if base%2 == 1 {
+		sos = bidi.R
+	}
+
+	// W1: non-spacing marks take the type of the previous character
+	for i, c := range classes {
+		if c == bidi.NSM {
+			classes[i] = sos
+			if i > 0 {
+				classes[i] = classes[i-1]
+			}
+		}
+	}
+	// W2: European numbers after Arabic letters are Arabic numbers; W3:
+	// Arabic letters are right-to-left
+	strong := sos
+	for i, c := range classes {
+		switch c {
+		case bidi.L, bidi.R, bidi.AL:
+			strong = c
+		case bidi.EN:
+			if strong == bidi.AL {
+				classes[i] = bidi.AN
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == bidi.AL {
+			classes[i] = bidi.R
+		}
+	}
+	// W4: a single separator between two numbers of the same type joins them
+	for i := 1; i < len(classes)-1; i++ {
+		before, after := classes[i-1], classes[i+1]
+		switch {
+		case classes[i] == bidi.ES && before == bidi.EN && after == bidi.EN:
+			classes[i] = bidi.EN
+		case classes[i] == bidi.CS && before == after && (before == bidi.EN || before == bidi.AN):
+			classes[i] = before
+		}
+	}
+	// W5: terminators (e.g. currency symbols) next to European numbers are
+	// part of them
+	for i := 0; i < len(classes); {
+		if classes[i] != bidi.ET {
+			i++
+			continue
+		}
+		end := i
+		for end < len(classes) && classes[end] == bidi.ET {
+			end++
+		}
+		if (i > 0 && classes[i-1] == bidi.EN) || (end < len(classes) && classes[end] == bidi.EN) {
+			for j := i; j < end; j++ {
+				classes[j] = bidi.EN
+			}
+		}
+		i = end
+	}
+	// W6: remaining separators and terminators are neutrals; W7: European
+	// numbers after left-to-right text are left-to-right
+	strong = sos
+	for i, c := range classes {
+		switch c {
+		case bidi.ES, bidi.ET, bidi.CS:
+			classes[i] = bidi.ON
+		case bidi.L, bidi.R:
+			strong = c
+		case bidi.EN:
+			if strong == bidi.L {
+				classes[i] = bidi.L
+			}
+		}
+	}
+
+	// N1 and N2: neutrals take the direction of the surrounding text if it
+	// is the same on both sides, and the base direction otherwise
+	direction := func(i int) bidi.Class {
+		if i < 0 || i >= len(classes) {
+			return sos
+		}
+		if classes[i] == bidi.L {
+			return bidi.L
+		}
+		return bidi.R
+	}
+	neutral := func(c bidi.Class) bool {
+		switch c {
+		case bidi.L, bidi.R, bidi.EN, bidi.AN:
+			return false
+		}
+		return true
+	}
+	for i := 0; i < len(classes); {
+		if !neutral(classes[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(classes) && neutral(classes[end]) {
+			end++
+		}
+		resolved := sos
+		if before := direction(i - 1); before == direction(end) {
+			resolved = before
+		}
+		for j := i; j < end; j++ {
+			classes[j] = resolved
+		}
+		i = end
+	}
+
+	// I1 and I2: raise the level of the characters whose direction differs
+	// from the base one
+	result := make([]uint8, len(classes))
+	for i, c := range classes {
+		result[i] = base
+		switch {
+		case base%2 == 0 && c == bidi.R:
+			result[i] = base + 1
+		case base%2 == 0 && (c == bidi.AN || c == bidi.EN):
+			result[i] = base + 2
+		case base%2 == 1 && c != bidi.R:
+			result[i] = base + 1
+		}
+	}
+	return result
+}
+
+// itemize splits a paragraph into the ranges of characters, given by their
+// end, with the same embedding level, script and style (given as an index
+// for each character); characters common to all scripts (e.g. spaces and
+// punctuation) join the surrounding ones.
+func itemize(text []rune, levels []uint8, styles []int) ([]int, []language.Script) {
+	var (
+		ends    []int
+		scripts []language.Script
+	)
+	current := language.Common
+	for i, r := range text {
+		script := language.LookupScript(r)
+		switch {
+		case i > 0 && levels[i] != levels[i-1]:
+			ends = append(ends, i)
+			scripts = append(scripts, current)
+			current = language.Common
+		case i > 0 && styles[i] != styles[i-1]:
+			ends = append(ends, i)
+			scripts = append(scripts, current)
+		case script.Strong() && current.Strong() && script != current:
+			ends = append(ends, i)
+			scripts = append(scripts, current)
+		}
+		if script.Strong() {
+			current = script
+		}
+	}
+	ends = append(ends, len(text))
+	scripts = append(scripts, current)
+	return ends, scripts
+}
+
+// reorder returns the runs of a line, given in logical order, in visual order
+// from left to right, by reversing each sequence of runs at or above every
+// odd level (rule L2 of the Unicode bidi algorithm).
+func reorder(runs shaping.Line, levels []uint8) shaping.Line {
+	result := make(shaping.Line, len(runs))
+	copy(result, runs)
+	level := func(run shaping.Output) uint8 {
+		return levels[run.Runes.Offset]
+	}
+	highest, lowest := uint8(0), uint8(255)
+	for _, run := range result {
+		highest = max(highest, level(run))
+		if level(run)%2 == 1 {
+			lowest = min(lowest, level(run))
+		}
+	}
+	for l := highest; l >= lowest && l > 0; l-- {
+		for i := 0; i < len(result); {
+			if level(result[i]) < l {
+				i++
+				continue
+			}
+			end := i
+			for end < len(result) && level(result[end]) >= l {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				result[a], result[b] = result[b], result[a]
+			}
+			i = end
+		}
+	}
+	return result
+}
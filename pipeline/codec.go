@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// Decode decodes an image from the given reader; the format (one of jpeg,
+// png, gif, bmp, tiff or webp) is detected automatically and returned along
+// with the image.
+func Decode(r io.Reader) (image.Image, string, error) {
+	return image.Decode(r)
+}
+
+// Load reads and decodes the image in the given file; - stands for STDIN.
+func Load(path string) (image.Image, error) {
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		input = f
+	}
+	img, _, err := Decode(input)
+	return img, err
+}
+
+// FormatFromPath returns the image format associated with the extension of
+// the given file name.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return "jpg", nil
+	case ".png":
+		return "png", nil
+	case ".gif":
+		return "gif", nil
+	case ".bmp":
+		return "bmp", nil
+	case ".tif", ".tiff":
+		return "tiff", nil
+	}
+	return "", fmt.Errorf("unsupported output file type: %s", filepath.Ext(path))
+}
+
+// Encoder encodes images in a given format; the zero value of each of the
+// format-specific options selects the default of the underlying encoder.
+type Encoder struct {
+	// Format is the output format: jpeg (or jpg), png, gif, bmp or tiff (or tif).
+	Format string
+	// Quality is the JPEG quality, from 1 to 100.
+	Quality int
+	// PNGCompression is the PNG compression level.
+	PNGCompression png.CompressionLevel
+	// Colours is the maximum number of colours in the GIF palette, from 1 to 256.
+	Colours int
+	// Quantizer builds the GIF palette; if nil, the Plan 9 palette is used.
+	Quantizer draw.Quantizer
+	// Drawer converts the image to the GIF palette; if nil, Floyd-Steinberg
+	// error diffusion is used; use draw.Src to disable dithering.
+	Drawer draw.Drawer
+	// TIFFCompression is the TIFF compression type; only tiff.Uncompressed
+	// and tiff.Deflate are supported.
+	TIFFCompression tiff.CompressionType
+	// DPI is the physical resolution stored in the image metadata; it is
+	// ignored for formats that cannot carry it (e.g. GIF) and if zero.
+	DPI float64
+}
+
+// Encode writes the image to the given writer in the encoder's format.
+func (e *Encoder) Encode(w io.Writer, img image.Image) error {
+	if e.DPI <= 0 {
+		return e.encode(w, img)
+	}
+
+	// the resolution is set by patching the encoded image
+	buffer := &bytes.Buffer{}
+	if err := e.encode(buffer, img); err != nil {
+		return err
+	}
+	_, err := w.Write(SetResolution(buffer.Bytes(), e.DPI))
+	return err
+}
+
+// encode writes the image to the given writer in the encoder's format,
+// without any resolution information.
+func (e *Encoder) encode(w io.Writer, img image.Image) error {
+	switch e.Format {
+	case "jpg", "jpeg":
+		options := &jpeg.Options{Quality: jpeg.DefaultQuality}
+		if e.Quality > 0 {
+			options.Quality = e.Quality
+		}
+		return jpeg.Encode(w, img, options)
+	case "png":
+		encoder := &png.Encoder{CompressionLevel: e.PNGCompression}
+		return encoder.Encode(w, img)
+	case "gif":
+		options := &gif.Options{
+			NumColors: 256,
+			Quantizer: e.Quantizer,
+			Drawer:    e.Drawer,
+		}
+		if e.Colours > 0 {
+			options.NumColors = e.Colours
+		}
+		return gif.Encode(w, img, options)
+	case "bmp":
+		return bmp.Encode(w, img)
+	case "tif", "tiff":
+		return tiff.Encode(w, img, &tiff.Options{Compression: e.TIFFCompression})
+	}
+	return fmt.Errorf("unsupported output format: %s", e.Format)
+}
+
+// Encode writes the image to the given writer in the given format, using
+// the default encoding options.
+func Encode(w io.Writer, img image.Image, format string) error {
+	return (&Encoder{Format: format}).Encode(w, img)
+}
+
+// Save encodes the image into the given file, in the format associated with
+// its extension; if the image cannot be encoded, the file is removed, so that
+// no truncated image is left behind.
+func Save(path string, img image.Image) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Encode(f, img, format); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
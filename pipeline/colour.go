@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ParseColour parses a colour in the format #RGB, #RGBA, #RRGGBB or
+// #RRGGBBAA; in the short formats each digit is doubled, so that #F80 is the
+// same as #FF8800.
+func ParseColour(value string) (color.NRGBA, error) {
+	digits, ok := strings.CutPrefix(value, "#")
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q: missing leading #", value)
+	}
+	switch len(digits) {
+	case 3, 4:
+		expanded := make([]byte, 0, 2*len(digits))
+		for i := 0; i < len(digits); i++ {
+			expanded = append(expanded, digits[i], digits[i])
+		}
+		digits = string(expanded)
+	case 6, 8:
+	default:
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q: expected #RGB, #RGBA, #RRGGBB or #RRGGBBAA", value)
+	}
+	if len(digits) == 6 {
+		digits += "FF"
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q: %w", value, err)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exifTags are the names of the EXIF tags that are read from images, as
+// found in the first Image File Directory or in the EXIF one.
+var exifTags = map[uint16]string{
+	0x010E: "ImageDescription",
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x920A: "FocalLength",
+	0xA405: "FocalLengthIn35mmFilm",
+	0xA433: "LensMake",
+	0xA434: "LensModel",
+}
+
+// TIFF tags and types used to read EXIF metadata.
+const (
+	exifIFDPointer   = 0x8769
+	exifExposureTime = 0x829A
+	tiffASCII        = 2
+	tiffShort        = 3
+	tiffLong         = 4
+	tiffSRational    = 10
+)
+
+// EXIF returns the EXIF fields stored in the metadata of the given encoded
+// image, by name (e.g. "Model" or "DateTimeOriginal"), formatted as text;
+// dates are in the EXIF format, e.g. "2024:06:30 18:45:00". Supported formats
+// are JPEG (APP1 segment), PNG (eXIf chunk) and TIFF; the result is empty if
+// the image carries no EXIF metadata.
+func EXIF(data []byte) map[string]string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngEXIF(data)
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		return jpegEXIF(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return tiffEXIF(data)
+	}
+	return map[string]string{}
+}
+
+// jpegEXIF reads the EXIF fields from the APP1 segment of a JPEG image.
+func jpegEXIF(data []byte) map[string]string {
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xFF; {
+		marker := data[offset+1]
+		// start of scan: no more metadata segments
+		if marker == 0xDA {
+			break
+		}
+		// the length includes its own two bytes, so shorter ones are malformed
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			break
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffEXIF(segment[6:])
+		}
+		offset += 2 + length
+	}
+	return map[string]string{}
+}
+
+// pngEXIF reads the EXIF fields from the eXIf chunk of a PNG image.
+func pngEXIF(data []byte) map[string]string {
+	for offset := 8; offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if offset+12+length > len(data) {
+			break
+		}
+		if string(data[offset+4:offset+8]) == "eXIf" {
+			return tiffEXIF(data[offset+8 : offset+8+length])
+		}
+		offset += 12 + length
+	}
+	return map[string]string{}
+}
+
+// tiffEXIF reads the EXIF fields from the first Image File Directory of TIFF
+// data and from the EXIF Image File Directory it points to, if any.
+func tiffEXIF(data []byte) map[string]string {
+	fields := map[string]string{}
+	entries, order := ifd(data)
+	for _, entry := range entries {
+		if entry.tag == exifIFDPointer && entry.kind == tiffLong {
+			entries = append(entries, ifdAt(data, int(order.Uint32(data[entry.offset+8:])), order)...)
+		}
+	}
+	for _, entry := range entries {
+		name, ok := exifTags[entry.tag]
+		if !ok {
+			continue
+		}
+		if value, ok := entry.text(data, order); ok {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// text returns the value of an entry formatted as text; only the first value
+// of entries holding several numbers is returned.
+func (e ifdEntry) text(data []byte, order binary.ByteOrder) (string, bool) {
+	size := map[uint16]int{tiffASCII: 1, tiffShort: 2, tiffLong: 4, tiffRational: 8, tiffSRational: 8}[e.kind]
+	if size == 0 || e.count == 0 {
+		return "", false
+	}
+	// values of up to four bytes are stored in the entry itself
+	position := e.offset + 8
+	if size*int(e.count) > 4 {
+		position = int(order.Uint32(data[e.offset+8:]))
+	}
+	if position <= 0 || position+size*int(e.count) > len(data) {
+		return "", false
+	}
+
+	switch e.kind {
+	case tiffASCII:
+		value := strings.TrimSpace(strings.TrimRight(string(data[position:position+int(e.count)]), "\x00"))
+		return value, value != ""
+	case tiffShort:
+		return strconv.Itoa(int(order.Uint16(data[position:]))), true
+	case tiffLong:
+		return strconv.FormatUint(uint64(order.Uint32(data[position:])), 10), true
+	}
+	var numerator, denominator float64
+	if e.kind == tiffRational {
+		numerator, denominator = float64(order.Uint32(data[position:])), float64(order.Uint32(data[position+4:]))
+	} else {
+		numerator, denominator = float64(int32(order.Uint32(data[position:]))), float64(int32(order.Uint32(data[position+4:])))
+	}
+	if denominator == 0 {
+		return "", false
+	}
+	// fractions of a second are given as such, e.g. 1/250
+	if e.tag == exifExposureTime && numerator > 0 && numerator < denominator {
+		return fmt.Sprintf("1/%g", denominator/numerator), true
+	}
+	return strconv.FormatFloat(numerator/denominator, 'g', 4, 64), true
+}
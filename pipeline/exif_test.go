@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestMalformedJPEGMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty APP0 segment", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x00, 0xFF, 0xD9}},
+		{"APP0 segment of length 1", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01, 0xFF, 0xD9}},
+		{"empty APP1 segment", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00, 0xFF, 0xD9}},
+		{"APP1 segment of length 1", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xD9}},
+		{"truncated APP1 segment", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x40, 'E', 'x', 'i', 'f'}},
+		{"truncated marker", []byte{0xFF, 0xD8, 0xFF}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if dpi, ok := Resolution(test.data); ok {
+				t.Errorf("unexpected resolution %g", dpi)
+			}
+			if fields := EXIF(test.data); len(fields) != 0 {
+				t.Errorf("unexpected EXIF fields %v", fields)
+			}
+		})
+	}
+}
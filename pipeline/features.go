@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
+)
+
+// Feature is an OpenType feature of a font, e.g. small capitals (smcp) or
+// tabular numerals (tnum), with its value: 0 disables the feature, 1 enables
+// it, and larger values select among alternates.
+type Feature struct {
+	// Tag is the four-letter tag of the feature.
+	Tag string
+	// Value is the value the feature is set to.
+	Value uint32
+}
+
+// ParseFeatures parses a comma-separated list of OpenType features, each
+// given by its tag (e.g. "smcp" to enable it), by its tag prefixed with a
+// minus sign (e.g. "-liga" to disable it), or by its tag and value (e.g.
+// "salt=2").
+func ParseFeatures(value string) ([]Feature, error) {
+	var features []Feature
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		feature := Feature{Tag: item, Value: 1}
+		if tag, ok := strings.CutPrefix(item, "-"); ok {
+			feature = Feature{Tag: tag, Value: 0}
+		} else if tag, v, ok := strings.Cut(item, "="); ok {
+			n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid feature %q: %w", item, err)
+			}
+			feature = Feature{Tag: strings.TrimSpace(tag), Value: uint32(n)}
+		}
+		if len(feature.Tag) != 4 {
+			return nil, fmt.Errorf("invalid feature %q: tags are four characters long", item)
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// Variation is the value of an axis of a variable font, e.g. its weight
+// (wght) or width (wdth).
+type Variation struct {
+	// Axis is the four-letter tag of the axis.
+	Axis string
+	// Value is the value of the axis, in its design units (e.g. 100 to 900
+	// for the weight).
+	Value float64
+}
+
+// ParseVariations parses a comma-separated list of axis values of variable
+// fonts, each given by the tag of the axis and its value separated by a
+// colon (e.g. "wght:650,wdth:80").
+func ParseVariations(value string) ([]Variation, error) {
+	var variations []Variation
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		axis, v, ok := strings.Cut(item, ":")
+		axis = strings.TrimSpace(axis)
+		if !ok || len(axis) != 4 {
+			return nil, fmt.Errorf("invalid variation %q: expected a four-letter axis and a value, e.g. wght:650", item)
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid variation %q: %w", item, err)
+		}
+		variations = append(variations, Variation{Axis: axis, Value: n})
+	}
+	return variations, nil
+}
+
+// features returns the OpenType features the text is shaped with.
+func (style TextStyle) features() []shaping.FontFeature {
+	features := make([]shaping.FontFeature, 0, len(style.Features)+1)
+	if style.NoKerning {
+		features = append(features, shaping.FontFeature{Tag: ot.MustNewTag("kern"), Value: 0})
+	}
+	for _, f := range style.Features {
+		if len(f.Tag) != 4 {
+			slog.Warn("invalid font feature ignored", "tag", f.Tag)
+			continue
+		}
+		features = append(features, shaping.FontFeature{Tag: ot.MustNewTag(f.Tag), Value: f.Value})
+	}
+	return features
+}
+
+// variations returns the axis values applied to variable fonts.
+func (style TextStyle) variations() []font.Variation {
+	variations := make([]font.Variation, 0, len(style.Variations))
+	for _, v := range style.Variations {
+		if len(v.Axis) != 4 {
+			slog.Warn("invalid font variation ignored", "axis", v.Axis)
+			continue
+		}
+		variations = append(variations, font.Variation{Tag: ot.MustNewTag(v.Axis), Value: float32(v.Value)})
+	}
+	return variations
+}
@@ -0,0 +1,371 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/fontscan"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// FontPathVariable is the environment variable holding a list of additional
+// directories fonts are looked for in, separated as in PATH; they are
+// searched before the standard font directories of the system.
+const FontPathVariable = "OVERLAY_FONT_PATH"
+
+// ErrFontNotFound is returned when no font matches a family name.
+var ErrFontNotFound = errors.New("font not found")
+
+// Font is a TrueType or OpenType font.
+type Font struct {
+	// Name is the name of the file the font was loaded from, or that of the
+	// font if it is built-in.
+	Name string
+	face *font.Face
+	// path is the file the font was loaded from; it is empty for built-in
+	// fonts.
+	path string
+	// family and aspect describe the font, to find its variants.
+	family string
+	aspect font.Aspect
+	// variants caches the fonts of the same family with other aspects.
+	variants map[font.Aspect]*Font
+}
+
+// newFont returns a font with the given face, described by its metadata.
+func newFont(name, path string, face *font.Face) *Font {
+	description := face.Describe()
+	description.Aspect.SetDefaults()
+	return &Font{Name: name, face: face, path: path, family: description.Family, aspect: description.Aspect}
+}
+
+// LoadFont loads a TrueType or OpenType font from the given file; if the file
+// is a font collection, its first font is used.
+func LoadFont(path string) (*Font, error) {
+	return loadFont(path, 0)
+}
+
+// loadFont loads the font at the given index within a TrueType or OpenType
+// font file (or collection).
+func loadFont(path string, index int) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	faces, err := font.ParseTTC(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid font %s: %w", path, err)
+	}
+	if index >= len(faces) {
+		return nil, fmt.Errorf("invalid font %s: no font at index %d", path, index)
+	}
+	return newFont(path, path, faces[index]), nil
+}
+
+// variant returns the font of the same family in bold and/or italic, looking
+// for it next to the font file first, then among all the available fonts;
+// if there is no such font, the closest one (possibly the font itself) is
+// returned.
+func (f *Font) variant(bold, italic bool) *Font {
+	aspect := f.aspect
+	if bold {
+		aspect.Weight = max(aspect.Weight, font.WeightBold)
+	}
+	if italic {
+		aspect.Style = font.StyleItalic
+	}
+	if aspect == f.aspect {
+		return f
+	}
+	if v, ok := f.variants[aspect]; ok {
+		return v
+	}
+
+	var siblings []FontInfo
+	if f.path != "" {
+		directory := filepath.Dir(f.path)
+		entries, _ := os.ReadDir(directory)
+		for _, entry := range entries {
+			if isFontFile(entry.Name()) {
+				siblings = append(siblings, describe(filepath.Join(directory, entry.Name()))...)
+			}
+		}
+	}
+	best, ok := closest(siblings, f.family, aspect)
+	if !ok || distance(best.aspect, aspect) > 0 {
+		if other, found := closest(Fonts(), f.family, aspect); found && (!ok || distance(other.aspect, aspect) < distance(best.aspect, aspect)) {
+			best, ok = other, true
+		}
+	}
+
+	v := f
+	if ok && distance(best.aspect, aspect) < distance(f.aspect, aspect) {
+		loaded, err := best.Load()
+		if err != nil {
+			slog.Warn("error loading font variant", "font", best.Name(), "path", best.Path, "error", err)
+		} else {
+			slog.Debug("font variant found", "font", f.Name, "bold", bold, "italic", italic, "variant", best.Name(), "path", best.Path)
+			v = loaded
+		}
+	} else {
+		slog.Warn("no font variant found", "font", f.Name, "bold", bold, "italic", italic)
+	}
+	if f.variants == nil {
+		f.variants = map[font.Aspect]*Font{}
+	}
+	f.variants[aspect] = v
+	return v
+}
+
+// FontInfo describes a font available by family name.
+type FontInfo struct {
+	// Family is the name of the font family, e.g. "DejaVu Sans".
+	Family string
+	// Style is the weight and slant of the font, e.g. "bold italic".
+	Style string
+	// Path is the file the font is stored in; it is empty for built-in fonts.
+	Path string
+	// Index is the position of the font within a font collection file.
+	Index int
+	// aspect is used to match the font against the requested style.
+	aspect font.Aspect
+	// data holds the contents of built-in fonts.
+	data []byte
+}
+
+// Name returns the name the font can be referred to by, i.e. its family and
+// style separated by a colon.
+func (info FontInfo) Name() string {
+	return info.Family + ":" + info.Style
+}
+
+// Load loads the described font.
+func (info FontInfo) Load() (*Font, error) {
+	if info.Path == "" {
+		face, err := font.ParseTTF(bytes.NewReader(info.data))
+		if err != nil {
+			return nil, err
+		}
+		return newFont(info.Name(), "", face), nil
+	}
+	return loadFont(info.Path, info.Index)
+}
+
+// builtins are the fonts embedded in the application, from the Go family.
+var builtins = []FontInfo{
+	{Family: "Go", Style: "regular", aspect: font.Aspect{Style: font.StyleNormal, Weight: font.WeightNormal}, data: goregular.TTF},
+	{Family: "Go", Style: "bold", aspect: font.Aspect{Style: font.StyleNormal, Weight: font.WeightBold}, data: gobold.TTF},
+	{Family: "Go", Style: "italic", aspect: font.Aspect{Style: font.StyleItalic, Weight: font.WeightNormal}, data: goitalic.TTF},
+	{Family: "Go", Style: "bold italic", aspect: font.Aspect{Style: font.StyleItalic, Weight: font.WeightBold}, data: gobolditalic.TTF},
+}
+
+// DefaultFont returns the built-in font used when none is given.
+func DefaultFont() (*Font, error) {
+	return builtins[0].Load()
+}
+
+// weights are the names of the font weights, as used in styles.
+var weights = []struct {
+	name   string
+	weight font.Weight
+}{
+	{"thin", font.WeightThin},
+	{"extralight", font.WeightExtraLight},
+	{"light", font.WeightLight},
+	{"regular", font.WeightNormal},
+	{"medium", font.WeightMedium},
+	{"semibold", font.WeightSemibold},
+	{"bold", font.WeightBold},
+	{"extrabold", font.WeightExtraBold},
+	{"black", font.WeightBlack},
+}
+
+// style returns the name of the style of a font with the given aspect.
+func style(aspect font.Aspect) string {
+	name := "regular"
+	for _, w := range weights {
+		if aspect.Weight >= w.weight-50 {
+			name = w.name
+		}
+	}
+	if aspect.Style == font.StyleItalic {
+		if name == "regular" {
+			return "italic"
+		}
+		return name + " italic"
+	}
+	return name
+}
+
+// parseStyle returns the aspect described by a style name, such as "bold",
+// "light italic" or "oblique"; unknown words are ignored.
+func parseStyle(name string) font.Aspect {
+	aspect := font.Aspect{Style: font.StyleNormal, Weight: font.WeightNormal}
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		switch word {
+		case "italic", "oblique":
+			aspect.Style = font.StyleItalic
+		case "normal":
+			aspect.Weight = font.WeightNormal
+		default:
+			for _, w := range weights {
+				if word == w.name {
+					aspect.Weight = w.weight
+				}
+			}
+		}
+	}
+	return aspect
+}
+
+// FontDirectories returns the directories fonts are looked for in: those
+// listed in the OVERLAY_FONT_PATH environment variable, followed by the
+// standard font directories of the system.
+func FontDirectories() []string {
+	directories := filepath.SplitList(os.Getenv(FontPathVariable))
+	system, err := fontscan.DefaultFontDirectories(logger{})
+	if err != nil {
+		slog.Warn("no standard font directories", "error", err)
+	}
+	return append(directories, system...)
+}
+
+// logger forwards the messages of the font scanner to the debug log.
+type logger struct{}
+
+// Printf logs a formatted message at debug level.
+func (logger) Printf(format string, args ...any) {
+	slog.Debug(fmt.Sprintf(format, args...))
+}
+
+// Fonts returns the fonts available by family name, i.e. those found in the
+// font directories and the built-in ones, sorted by family and style; the
+// directories are only scanned once.
+var Fonts = sync.OnceValue(func() []FontInfo {
+	fonts := slices.Clone(builtins)
+	for _, directory := range FontDirectories() {
+		slog.Debug("scanning font directory", "path", directory)
+		filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			if isFontFile(path) {
+				fonts = append(fonts, describe(path)...)
+			}
+			return nil
+		})
+	}
+	slices.SortStableFunc(fonts, func(a, b FontInfo) int {
+		if c := strings.Compare(a.Family, b.Family); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Style, b.Style)
+	})
+	slog.Debug("fonts scanned", "count", len(fonts))
+	return fonts
+})
+
+// isFontFile returns whether the given file is a font file (or collection),
+// judging from its extension.
+func isFontFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttf", ".otf", ".ttc", ".otc":
+		return true
+	}
+	return false
+}
+
+// describe returns the description of the fonts in the given file; files
+// that cannot be parsed are skipped.
+func describe(path string) []FontInfo {
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Debug("error opening font file", "path", path, "error", err)
+		return nil
+	}
+	defer file.Close()
+	loaders, err := ot.NewLoaders(file)
+	if err != nil {
+		slog.Debug("error parsing font file", "path", path, "error", err)
+		return nil
+	}
+	fonts := make([]FontInfo, 0, len(loaders))
+	for i, loader := range loaders {
+		description, _ := font.Describe(loader, nil)
+		if description.Family == "" {
+			continue
+		}
+		description.Aspect.SetDefaults()
+		fonts = append(fonts, FontInfo{
+			Family: description.Family,
+			Style:  style(description.Aspect),
+			Path:   path,
+			Index:  i,
+			aspect: description.Aspect,
+		})
+	}
+	return fonts
+}
+
+// FindFont loads a font given either the path to its file, or its family and
+// style separated by a colon (e.g. "DejaVu Sans:bold"); if the style is
+// omitted, the regular one is preferred, and if no font of the family has
+// the requested style, the closest one is used.
+func FindFont(name string) (*Font, error) {
+	if isFontFile(name) {
+		return LoadFont(name)
+	}
+	if _, err := os.Stat(name); err == nil {
+		return LoadFont(name)
+	}
+
+	family, styles, _ := strings.Cut(name, ":")
+	info, ok := closest(Fonts(), strings.TrimSpace(family), parseStyle(styles))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFontNotFound, name)
+	}
+	slog.Debug("font found", "name", name, "font", info.Name(), "path", info.Path)
+	return info.Load()
+}
+
+// closest returns the font of the given family whose aspect is the closest
+// to the requested one, if any.
+func closest(candidates []FontInfo, family string, aspect font.Aspect) (FontInfo, bool) {
+	var (
+		best  FontInfo
+		found bool
+	)
+	for _, info := range candidates {
+		if !strings.EqualFold(info.Family, family) {
+			continue
+		}
+		if !found || distance(info.aspect, aspect) < distance(best.aspect, aspect) {
+			best, found = info, true
+		}
+	}
+	return best, found
+}
+
+// distance measures how much a font aspect differs from the requested one;
+// a different slant weighs more than any difference in weight.
+func distance(aspect, requested font.Aspect) float64 {
+	d := math.Abs(float64(aspect.Weight - requested.Weight))
+	if aspect.Style != requested.Style {
+		d += 1000
+	}
+	return d
+}
@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/gogpu/gg"
+)
+
+// GradientKind is the geometry of a gradient.
+type GradientKind string
+
+const (
+	// LinearGradient varies the colour along the line between two points.
+	LinearGradient GradientKind = "linear"
+	// RadialGradient varies the colour from a focal point to a circle.
+	RadialGradient GradientKind = "radial"
+	// ConicGradient varies the colour around a centre, sweeping a whole turn.
+	ConicGradient GradientKind = "conic"
+)
+
+// Spread is how a gradient continues past its ends.
+type Spread string
+
+const (
+	// SpreadPad extends the colours at the ends.
+	SpreadPad Spread = "pad"
+	// SpreadRepeat repeats the gradient.
+	SpreadRepeat Spread = "repeat"
+	// SpreadReflect repeats the gradient, mirroring every other copy.
+	SpreadReflect Spread = "reflect"
+)
+
+// GradientStop is a colour at a position along a gradient, from 0 at its
+// start to 1 at its end.
+type GradientStop struct {
+	Offset float64
+	Colour color.Color
+}
+
+// Gradient is a smooth transition between colours, used to paint shapes.
+type Gradient struct {
+	// Kind is the geometry of the gradient.
+	Kind GradientKind
+	// X1, Y1, X2 and Y2 are the points a linear gradient runs between.
+	X1, Y1, X2, Y2 float64
+	// CX, CY and Radius are the centre and radius of the circle a radial
+	// gradient ends at; FX and FY are its focal point, where it starts. CX
+	// and CY are also the centre of a conic gradient.
+	CX, CY, Radius, FX, FY float64
+	// Angle is where a conic gradient starts, in degrees clockwise from the
+	// positive x axis.
+	Angle float64
+	// Stops are the colours of the gradient; if there are none, it is
+	// transparent.
+	Stops []GradientStop
+	// Spread is how the gradient continues past its ends; if empty, it is
+	// padded.
+	Spread Spread
+}
+
+// brush returns a brush painting the gradient, given the matrix mapping the
+// coordinates of the gradient to those of the image.
+func (g Gradient) brush(m gg.Matrix) gg.Brush {
+	stops := slices.Clone(g.Stops)
+	slices.SortStableFunc(stops, func(a, b GradientStop) int {
+		switch {
+		case a.Offset < b.Offset:
+			return -1
+		case a.Offset > b.Offset:
+			return 1
+		}
+		return 0
+	})
+	colours := make([]gg.RGBA, len(stops))
+	for i, stop := range stops {
+		colours[i] = gg.FromColor(stop.Colour)
+	}
+	inverse := m.Invert()
+	position := g.position()
+	return gg.NewCustomBrush(func(x, y float64) gg.RGBA {
+		if len(stops) == 0 {
+			return gg.Transparent
+		}
+		p := inverse.TransformPoint(gg.Pt(x, y))
+		t := g.Spread.apply(position(p.X, p.Y))
+		if t <= stops[0].Offset {
+			return colours[0]
+		}
+		for i := 1; i < len(stops); i++ {
+			if t <= stops[i].Offset {
+				span := stops[i].Offset - stops[i-1].Offset
+				if span <= 0 {
+					return colours[i]
+				}
+				return mix(colours[i-1], colours[i], (t-stops[i-1].Offset)/span)
+			}
+		}
+		return colours[len(colours)-1]
+	})
+}
+
+// position returns the function giving the position along the gradient, from
+// 0 at its start to 1 at its end, of a point in the coordinates of the
+// gradient.
+func (g Gradient) position() func(x, y float64) float64 {
+	switch g.Kind {
+	case ConicGradient:
+		start := radians(g.Angle)
+		return func(x, y float64) float64 {
+			t := (math.Atan2(y-g.CY, x-g.CX) - start) / (2 * math.Pi)
+			return t - math.Floor(t)
+		}
+	case RadialGradient:
+		// the point lies on the circle interpolated between the focal point,
+		// with no radius, and the end circle
+		dx, dy := g.CX-g.FX, g.CY-g.FY
+		a := dx*dx + dy*dy - g.Radius*g.Radius
+		return func(x, y float64) float64 {
+			qx, qy := x-g.FX, y-g.FY
+			b := qx*dx + qy*dy
+			c := qx*qx + qy*qy
+			if math.Abs(a) < 1e-9 {
+				if b == 0 {
+					return 0
+				}
+				return c / (2 * b)
+			}
+			discriminant := b*b - a*c
+			if discriminant < 0 {
+				return 0
+			}
+			return (b - math.Sqrt(discriminant)) / a
+		}
+	default:
+		dx, dy := g.X2-g.X1, g.Y2-g.Y1
+		length := dx*dx + dy*dy
+		return func(x, y float64) float64 {
+			if length == 0 {
+				return 1
+			}
+			return ((x-g.X1)*dx + (y-g.Y1)*dy) / length
+		}
+	}
+}
+
+// apply maps a position past the ends of a gradient back onto it.
+func (s Spread) apply(t float64) float64 {
+	switch s {
+	case SpreadRepeat:
+		return t - math.Floor(t)
+	case SpreadReflect:
+		t = math.Mod(math.Abs(t), 2)
+		if t > 1 {
+			return 2 - t
+		}
+		return t
+	}
+	return min(max(t, 0), 1)
+}
+
+// mix interpolates between two colours, with premultiplied alpha so that
+// transparent stops do not darken their neighbours.
+func mix(a, b gg.RGBA, t float64) gg.RGBA {
+	alpha := a.A + (b.A-a.A)*t
+	if alpha == 0 {
+		return gg.Transparent
+	}
+	channel := func(x, y float64) float64 {
+		return (x*a.A + (y*b.A-x*a.A)*t) / alpha
+	}
+	return gg.RGBA{R: channel(a.R, b.R), G: channel(a.G, b.G), B: channel(a.B, b.B), A: alpha}
+}
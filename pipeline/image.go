@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"image"
+	"log/slog"
+
+	"github.com/gogpu/gg"
+)
+
+// Image returns a Painter that superimposes the given image on the canvas,
+// with its top-left corner at (x, y); the parts of the image past the edges
+// of the canvas are clipped.
+func Image(overlay image.Image, x, y float64) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing image", "x", x, "y", y, "width", overlay.Bounds().Dx(), "height", overlay.Bounds().Dy())
+		c.context.DrawImage(gg.ImageBufFromImage(overlay), x, y)
+		return nil
+	}
+}
+
+// Clipped returns a Painter that runs the given painter with the canvas
+// clipped to the rectangle with the top-left corner at (x, y) and the given
+// width and height.
+func Clipped(x, y, width, height float64, painter Painter) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("clipping canvas", "x", x, "y", y, "width", width, "height", height)
+		c.context.Push()
+		defer c.context.Pop()
+		// the rectangle is clipped as a path, so that it follows rotations
+		c.context.DrawRectangle(x, y, width, height)
+		c.context.Clip()
+		return painter(c)
+	}
+}
+
+// Translucent returns a Painter that runs the given painter on a layer that
+// is then composited onto the canvas with the given opacity, from 0 (fully
+// transparent) to 1 (opaque).
+func Translucent(opacity float64, painter Painter) Painter {
+	return func(c *Canvas) error {
+		if opacity >= 1 {
+			return painter(c)
+		}
+		slog.Debug("painting on translucent layer", "opacity", opacity)
+		c.context.PushLayer(gg.BlendNormal, max(opacity, 0))
+		defer c.context.PopLayer()
+		return painter(c)
+	}
+}
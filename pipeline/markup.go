@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidMarkup is returned when rich text contains unknown, malformed or
+// unbalanced tags.
+var ErrInvalidMarkup = errors.New("invalid markup")
+
+// span is the formatting of a stretch of rich text.
+type span struct {
+	bold, italic bool
+	// colour is the colour of the text; if nil, that of the style is used.
+	colour color.Color
+	// size is the size of the font; if zero, that of the style is used.
+	size float64
+}
+
+// entities are the escape sequences for the characters that would otherwise
+// be taken as markup.
+var entities = map[string]rune{
+	"&lt;":  '<',
+	"&gt;":  '>',
+	"&amp;": '&',
+}
+
+// parseMarkup strips the tags from rich text, returning the plain text along
+// with the spans it is formatted with and, for each character, the index of
+// its span; the first span is the unformatted one. The tags are <b> (bold),
+// <i> (italic), <colour=#RRGGBB> (or <color=...>) and <size=N>, each closed
+// by the matching </b>, </i>, </colour> or </size>; tags can be nested, and
+// &lt;, &gt; and &amp; stand for <, > and &.
+func parseMarkup(s string) ([]rune, []span, []int, error) {
+	type tag struct {
+		name string
+		span span
+	}
+	var (
+		text    []rune
+		indices []int
+		stack   []tag
+	)
+	spans := []span{{}}
+	current := func() span {
+		if len(stack) == 0 {
+			return span{}
+		}
+		return stack[len(stack)-1].span
+	}
+	add := func(r rune) {
+		index := slices.Index(spans, current())
+		if index < 0 {
+			index = len(spans)
+			spans = append(spans, current())
+		}
+		text = append(text, r)
+		indices = append(indices, index)
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '&':
+			found := false
+			for entity, r := range entities {
+				if strings.HasPrefix(s[i:], entity) {
+					add(r)
+					i += len(entity)
+					found = true
+					break
+				}
+			}
+			if !found {
+				add('&')
+				i++
+			}
+			continue
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				return nil, nil, nil, fmt.Errorf("%w: unterminated tag at offset %d", ErrInvalidMarkup, i)
+			}
+			content := strings.TrimSpace(s[i+1 : i+end])
+			i += end + 1
+			if name, ok := strings.CutPrefix(content, "/"); ok {
+				name = canonical(strings.TrimSpace(name))
+				if len(stack) == 0 || stack[len(stack)-1].name != name {
+					return nil, nil, nil, fmt.Errorf("%w: unexpected closing tag </%s>", ErrInvalidMarkup, name)
+				}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			name, value, _ := strings.Cut(content, "=")
+			name, value = canonical(strings.TrimSpace(name)), strings.TrimSpace(value)
+			next := current()
+			switch name {
+			case "b":
+				next.bold = true
+			case "i":
+				next.italic = true
+			case "colour":
+				colour, err := ParseColour(value)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidMarkup, err)
+				}
+				next.colour = colour
+			case "size":
+				size, err := strconv.ParseFloat(value, 64)
+				if err != nil || size <= 0 {
+					return nil, nil, nil, fmt.Errorf("%w: invalid size %q", ErrInvalidMarkup, value)
+				}
+				next.size = size
+			default:
+				return nil, nil, nil, fmt.Errorf("%w: unknown tag <%s>", ErrInvalidMarkup, content)
+			}
+			stack = append(stack, tag{name: name, span: next})
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		add(r)
+		i += size
+	}
+	if len(stack) > 0 {
+		return nil, nil, nil, fmt.Errorf("%w: unclosed tag <%s>", ErrInvalidMarkup, stack[len(stack)-1].name)
+	}
+	return text, spans, indices, nil
+}
+
+// canonical returns the canonical name of a tag, which may be spelt in
+// different ways.
+func canonical(name string) string {
+	name = strings.ToLower(name)
+	if name == "color" {
+		return "colour"
+	}
+	return name
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/gogpu/gg"
+)
+
+// ErrInvalidPath is returned when SVG path data cannot be parsed.
+var ErrInvalidPath = errors.New("invalid path data")
+
+// PathTransform places the shape described by SVG path data in the image:
+// the shape is scaled, then rotated clockwise around the origin of its
+// coordinates, then translated.
+type PathTransform struct {
+	// ScaleX and ScaleY are the scale factors along the two axes.
+	ScaleX, ScaleY float64
+	// Angle is the clockwise rotation, in degrees.
+	Angle float64
+	// X and Y are the translation, in pixels.
+	X, Y float64
+}
+
+// matrix returns the transformation matrix equivalent to the transform.
+func (t PathTransform) matrix() gg.Matrix {
+	return gg.Translate(t.X, t.Y).Multiply(gg.Rotate(radians(t.Angle))).Multiply(gg.Scale(t.ScaleX, t.ScaleY))
+}
+
+// parsePath parses SVG path data (the "d" attribute of a <path> element,
+// with the move, line, horizontal and vertical line, cubic and quadratic
+// Bézier, smooth curve, elliptical arc and close commands, both absolute and
+// relative) and applies the given transform to it.
+func parsePath(data string, transform PathTransform) (*gg.Path, error) {
+	path, err := gg.ParseSVGPath(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPath, err)
+	}
+	return path.Transform(transform.matrix()), nil
+}
+
+// PathBounds returns the top-left corner and the size of the bounding box of
+// the shape described by SVG path data, once transformed; the box encloses
+// the control points of the curves too, so it may be slightly larger than
+// the shape.
+func PathBounds(data string, transform PathTransform) (float64, float64, float64, float64, error) {
+	path, err := parsePath(data, transform)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	coords := path.Coords()
+	if len(coords) == 0 {
+		return 0, 0, 0, 0, nil
+	}
+	left, top := math.Inf(1), math.Inf(1)
+	right, bottom := math.Inf(-1), math.Inf(-1)
+	for i := 0; i+1 < len(coords); i += 2 {
+		left, right = min(left, coords[i]), max(right, coords[i])
+		top, bottom = min(top, coords[i+1]), max(bottom, coords[i+1])
+	}
+	return left, top, right - left, bottom - top, nil
+}
+
+// Path returns a Painter that draws the shape described by SVG path data,
+// placed in the image by the given transform; the width of the stroke, if
+// any, is not affected by the transform.
+func Path(data string, transform PathTransform, style Style) Painter {
+	return func(c *Canvas) error {
+		path, err := parsePath(data, transform)
+		if err != nil {
+			return err
+		}
+		slog.Debug("drawing path", "verbs", path.NumVerbs(), "transform", transform)
+		c.context.DrawPath(path)
+		return style.paint(c.context)
+	}
+}
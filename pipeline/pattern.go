@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/gogpu/gg"
+)
+
+// PatternMode is how an image is laid out to fill a shape.
+type PatternMode string
+
+const (
+	// PatternRepeat tiles the image, starting from the top-left corner of
+	// the box.
+	PatternRepeat PatternMode = "repeat"
+	// PatternNoRepeat draws the image once, at the top-left corner of the
+	// box, leaving the rest of the shape unpainted.
+	PatternNoRepeat PatternMode = "no-repeat"
+	// PatternStretch resizes the image to fill the box.
+	PatternStretch PatternMode = "stretch"
+)
+
+// Pattern is an image used to paint shapes and text, instead of a colour.
+type Pattern struct {
+	// Image is the image the shape is painted with.
+	Image image.Image
+	// Mode is how the image is laid out; if empty, it is repeated.
+	Mode PatternMode
+	// X, Y, Width and Height are the box the image is laid out in, usually
+	// that enclosing the shape: the image, or its first tile, starts at its
+	// top-left corner, and a stretched image fills it.
+	X, Y, Width, Height float64
+}
+
+// brush returns a brush painting the pattern, given the matrix mapping the
+// coordinates of the box to those of the image.
+func (p Pattern) brush(m gg.Matrix) gg.Brush {
+	img := p.Image
+	if p.Mode == PatternStretch {
+		if w, h := int(math.Round(p.Width)), int(math.Round(p.Height)); w > 0 && h > 0 {
+			img = Resize(w, h, FilterLinear)(img)
+		}
+	}
+	// the pixels are copied once, so that they can be read quickly
+	bounds := img.Bounds()
+	pixels := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(pixels, pixels.Bounds(), img, bounds.Min, draw.Src)
+	width, height := pixels.Rect.Dx(), pixels.Rect.Dy()
+	scaleX, scaleY := 1.0, 1.0
+	if p.Mode == PatternStretch && p.Width > 0 && p.Height > 0 {
+		scaleX, scaleY = float64(width)/p.Width, float64(height)/p.Height
+	}
+
+	inverse := m.Invert()
+	return gg.NewCustomBrush(func(x, y float64) gg.RGBA {
+		if width == 0 || height == 0 {
+			return gg.Transparent
+		}
+		q := inverse.TransformPoint(gg.Pt(x, y))
+		u := int(math.Floor((q.X - p.X) * scaleX))
+		v := int(math.Floor((q.Y - p.Y) * scaleY))
+		if p.Mode == PatternRepeat || p.Mode == "" {
+			u, v = modulo(u, width), modulo(v, height)
+		} else if u < 0 || v < 0 || u >= width || v >= height {
+			return gg.Transparent
+		}
+		return gg.FromColor(pixels.NRGBAAt(u, v))
+	})
+}
+
+// modulo returns the remainder of the division of a by b, which is never
+// negative for positive b.
+func modulo(a, b int) int {
+	return ((a % b) + b) % b
+}
+
+// Hatch is a built-in pattern, drawn in a single colour on a transparent
+// tile.
+type Hatch string
+
+const (
+	// HatchHorizontal is made of horizontal lines.
+	HatchHorizontal Hatch = "horizontal"
+	// HatchVertical is made of vertical lines.
+	HatchVertical Hatch = "vertical"
+	// HatchDiagonal is made of lines rising from left to right.
+	HatchDiagonal Hatch = "diagonal"
+	// HatchBackDiagonal is made of lines falling from left to right.
+	HatchBackDiagonal Hatch = "back-diagonal"
+	// HatchCross is made of horizontal and vertical lines.
+	HatchCross Hatch = "cross"
+	// HatchDiagonalCross is made of lines in both diagonal directions.
+	HatchDiagonalCross Hatch = "diagonal-cross"
+	// HatchCheckerboard is made of alternating squares.
+	HatchCheckerboard Hatch = "checkerboard"
+	// HatchDots is made of round dots.
+	HatchDots Hatch = "dots"
+)
+
+// Hatches are the built-in patterns.
+var Hatches = []Hatch{
+	HatchHorizontal,
+	HatchVertical,
+	HatchDiagonal,
+	HatchBackDiagonal,
+	HatchCross,
+	HatchDiagonalCross,
+	HatchCheckerboard,
+	HatchDots,
+}
+
+// Tile returns a tile of the pattern of the given size, in pixels, drawn in
+// the given colour with lines of the given width; tiles join seamlessly
+// when repeated.
+func (h Hatch) Tile(size int, width float64, colour color.Color) (image.Image, error) {
+	size = max(size, 2)
+	c := NewCanvas(size, size)
+	defer c.Close()
+
+	dc := c.context
+	s := float64(size)
+	dc.SetColor(colour)
+	dc.SetStroke(gg.DefaultStroke().WithWidth(max(width, 1)))
+	line := func(x1, y1, x2, y2 float64) {
+		dc.MoveTo(x1, y1)
+		dc.LineTo(x2, y2)
+	}
+	switch h {
+	case HatchHorizontal, HatchCross:
+		line(0, s/2, s, s/2)
+		if h == HatchCross {
+			line(s/2, 0, s/2, s)
+		}
+	case HatchVertical:
+		line(s/2, 0, s/2, s)
+	case HatchDiagonal, HatchBackDiagonal, HatchDiagonalCross:
+		// lines are drawn past the corners, and shifted by a whole tile,
+		// so that they meet those of the neighbouring tiles
+		for k := -s; k <= s; k += s {
+			if h != HatchBackDiagonal {
+				line(k-1, s+1, k+s+1, -1)
+			}
+			if h != HatchDiagonal {
+				line(k-1, -1, k+s+1, s+1)
+			}
+		}
+	case HatchCheckerboard:
+		dc.DrawRectangle(0, 0, s/2, s/2)
+		dc.DrawRectangle(s/2, s/2, s/2, s/2)
+	case HatchDots:
+		dc.DrawCircle(s/2, s/2, max(width, s/5))
+	default:
+		return nil, fmt.Errorf("unknown pattern %q", h)
+	}
+	var err error
+	if h == HatchCheckerboard || h == HatchDots {
+		err = dc.Fill()
+	} else {
+		err = dc.Stroke()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cloneImage(c.Image()), nil
+}
+
+// cloneImage returns a copy of an image, which stays valid after the canvas
+// it was drawn on is closed.
+func cloneImage(img image.Image) image.Image {
+	clone := image.NewNRGBA(img.Bounds())
+	draw.Draw(clone, clone.Bounds(), img, img.Bounds().Min, draw.Src)
+	return clone
+}
@@ -1,6 +1,12 @@
+// Package pipeline provides the image processing facilities behind overlay,
+// in a form that can be embedded in other Go programs: images are decoded
+// with Decode or Load, painted on a Canvas by applying Painters (text, shapes,
+// images) to it, transformed with Transforms (crop, rotate, zoom, flip) and
+// finally written out with an Encoder, or with Encode or Save.
 package pipeline
 
 import (
+	"image"
 	"image/color"
 
 	"github.com/gogpu/gg"
@@ -22,6 +28,36 @@ func NewCanvas(sizeX, sizeY int) *Canvas {
 	}
 }
 
+// NewCanvasFromImage creates a new canvas using the given image as its
+// background; the canvas has the same size as the image.
+func NewCanvasFromImage(img image.Image) *Canvas {
+	return &Canvas{
+		sizeX:   img.Bounds().Dx(),
+		sizeY:   img.Bounds().Dy(),
+		context: gg.NewContextForImage(img),
+	}
+}
+
+// Width returns the width of the canvas, in pixels.
+func (c *Canvas) Width() int {
+	return c.sizeX
+}
+
+// Height returns the height of the canvas, in pixels.
+func (c *Canvas) Height() int {
+	return c.sizeY
+}
+
+// Context returns the device context used to paint on the canvas.
+func (c *Canvas) Context() *gg.Context {
+	return c.context
+}
+
+// Image returns a snapshot of the current contents of the canvas.
+func (c *Canvas) Image() image.Image {
+	return c.context.Image()
+}
+
 // Close releases the resources used by the canvas.
 func (c *Canvas) Close() error {
 	if c != nil && c.context != nil {
@@ -30,8 +66,11 @@ func (c *Canvas) Close() error {
 	return nil
 }
 
+// Painter is a function that paints something on a canvas.
 type Painter func(c *Canvas) error
 
+// Apply runs the given painters against the canvas, in order; it stops at
+// the first painter that returns an error.
 func (c *Canvas) Apply(painters ...Painter) (*Canvas, error) {
 	for _, paint := range painters {
 		if err := paint(c); err != nil {
@@ -41,15 +80,21 @@ func (c *Canvas) Apply(painters ...Painter) (*Canvas, error) {
 	return c, nil
 }
 
+// Backdrop returns a Painter that fills the whole canvas with the given colour.
 func Backdrop(color color.Color) Painter {
 	return func(c *Canvas) error {
-		r, g, b, a := color.RGBA()
-		c.context.ClearWithColor(gg.RGBA{
-			R: float64(r),
-			G: float64(g),
-			B: float64(b),
-			A: float64(a),
-		})
+		c.context.ClearWithColor(gg.FromColor(color))
 		return nil
 	}
 }
+
+// GradientBackdrop returns a Painter that replaces the whole canvas with the
+// given gradient.
+func GradientBackdrop(gradient Gradient) Painter {
+	return func(c *Canvas) error {
+		c.context.ClearWithColor(gg.Transparent)
+		c.context.DrawRectangle(0, 0, float64(c.sizeX), float64(c.sizeY))
+		c.context.SetFillBrush(gradient.brush(c.context.GetTransform()))
+		return c.context.Fill()
+	}
+}
@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"sort"
+)
+
+// WebSafe is a quantizer that always returns the 216-colour web-safe palette,
+// truncated to the requested number of colours.
+type WebSafe struct{}
+
+// Quantize implements the draw.Quantizer interface.
+func (WebSafe) Quantize(p color.Palette, _ image.Image) color.Palette {
+	n := min(cap(p)-len(p), len(palette.WebSafe))
+	return append(p, palette.WebSafe[:n]...)
+}
+
+// MedianCut is a quantizer that builds an adaptive palette out of the colours
+// actually used in the image, by recursively splitting the colour space at
+// the median of its widest channel.
+type MedianCut struct{}
+
+// bucket is a colour, reduced to 5 bits per channel, and the number of pixels
+// it accounts for.
+type bucket struct {
+	r, g, b uint8
+	count   int
+}
+
+// box is a set of buckets that will be represented by a single palette entry.
+type box []bucket
+
+// Quantize implements the draw.Quantizer interface.
+func (MedianCut) Quantize(p color.Palette, m image.Image) color.Palette {
+	n := cap(p) - len(p)
+	if n <= 0 {
+		return p
+	}
+
+	// build the histogram of the opaque colours, reserving an entry for the
+	// transparent colour if the image has transparent pixels
+	histogram := map[[3]uint8]int{}
+	transparent := false
+	bounds := m.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			if c.A < 0x80 {
+				transparent = true
+				continue
+			}
+			histogram[[3]uint8{c.R >> 3, c.G >> 3, c.B >> 3}]++
+		}
+	}
+	if transparent {
+		p = append(p, color.NRGBA{})
+		n--
+	}
+	if n <= 0 || len(histogram) == 0 {
+		return p
+	}
+
+	initial := make(box, 0, len(histogram))
+	for k, count := range histogram {
+		initial = append(initial, bucket{r: k[0], g: k[1], b: k[2], count: count})
+	}
+
+	// split the box with the widest range until there are enough boxes
+	boxes := []box{initial}
+	for len(boxes) < n {
+		index, widest := -1, 0
+		for i, b := range boxes {
+			if len(b) < 2 {
+				continue
+			}
+			if _, r := b.widest(); r > widest {
+				index, widest = i, r
+			}
+		}
+		if index < 0 {
+			// no box can be split any further
+			break
+		}
+		first, second := boxes[index].split()
+		boxes[index] = first
+		boxes = append(boxes, second)
+	}
+
+	for _, b := range boxes {
+		p = append(p, b.average())
+	}
+	return p
+}
+
+// channel returns the value of the given channel (0: red, 1: green, 2: blue).
+func (b bucket) channel(i int) uint8 {
+	switch i {
+	case 0:
+		return b.r
+	case 1:
+		return b.g
+	}
+	return b.b
+}
+
+// widest returns the channel with the widest range of values in the box,
+// along with the range itself.
+func (b box) widest() (int, int) {
+	channel, width := 0, -1
+	for i := range 3 {
+		lo, hi := uint8(255), uint8(0)
+		for _, c := range b {
+			lo = min(lo, c.channel(i))
+			hi = max(hi, c.channel(i))
+		}
+		if int(hi)-int(lo) > width {
+			channel, width = i, int(hi)-int(lo)
+		}
+	}
+	return channel, width
+}
+
+// split divides the box in two at the median pixel of its widest channel.
+func (b box) split() (box, box) {
+	channel, _ := b.widest()
+	sort.Slice(b, func(i, j int) bool {
+		return b[i].channel(channel) < b[j].channel(channel)
+	})
+	total := 0
+	for _, c := range b {
+		total += c.count
+	}
+	median, sum := 1, 0
+	for i, c := range b[:len(b)-1] {
+		sum += c.count
+		median = i + 1
+		if sum >= total/2 {
+			break
+		}
+	}
+	return b[:median], b[median:]
+}
+
+// average returns the colour representing the box, i.e. the average of the
+// colours in it weighted by the number of pixels.
+func (b box) average() color.Color {
+	var r, g, bl, total int
+	for _, c := range b {
+		r += int(c.r) * c.count
+		g += int(c.g) * c.count
+		bl += int(c.b) * c.count
+		total += c.count
+	}
+	// expand the 5-bit channels back to 8 bits
+	expand := func(v int) uint8 {
+		v /= total
+		return uint8(v<<3 | v>>2)
+	}
+	return color.NRGBA{R: expand(r), G: expand(g), B: expand(bl), A: 0xFF}
+}
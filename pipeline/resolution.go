@@ -0,0 +1,326 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+)
+
+const (
+	// inchesPerMeter is used to convert from pixels per meter to DPI.
+	inchesPerMeter = 39.3700787
+	// centimetersPerInch is used to convert from pixels per centimeter to DPI.
+	centimetersPerInch = 2.54
+)
+
+// Resolution returns the physical resolution, in DPI, stored in the metadata
+// of the given encoded image; the second return value is false if the format
+// is not supported or the image carries no resolution information. Supported
+// formats are PNG (pHYs chunk), JPEG (JFIF density or EXIF resolution), BMP
+// and TIFF.
+func Resolution(data []byte) (float64, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngResolution(data)
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		return jpegResolution(data)
+	case bytes.HasPrefix(data, []byte("BM")):
+		return bmpResolution(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return tiffResolution(data)
+	}
+	return 0, false
+}
+
+// SetResolution stores the given physical resolution, in DPI, in the metadata
+// of the given encoded image and returns the updated image data; images in
+// formats that cannot carry resolution information (e.g. GIF) are returned
+// unchanged.
+func SetResolution(data []byte, dpi float64) []byte {
+	if dpi <= 0 {
+		return data
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return setPNGResolution(data, dpi)
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		return setJPEGResolution(data, dpi)
+	case bytes.HasPrefix(data, []byte("BM")):
+		return setBMPResolution(data, dpi)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return setTIFFResolution(data, dpi)
+	}
+	return data
+}
+
+// fromPixelsPerMeter converts a resolution in pixels per meter to DPI; since
+// the former is an integer value, the result is rounded to one decimal place
+// so that common resolutions (e.g. 300 DPI) survive the round trip.
+func fromPixelsPerMeter(ppm uint32) float64 {
+	return math.Round(float64(ppm)/inchesPerMeter*10) / 10
+}
+
+// pngResolution reads the resolution from the pHYs chunk of a PNG image.
+func pngResolution(data []byte) (float64, bool) {
+	for offset := 8; offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		kind := string(data[offset+4 : offset+8])
+		if kind == "IDAT" || offset+12+length > len(data) {
+			break
+		}
+		if kind == "pHYs" && length == 9 {
+			chunk := data[offset+8:]
+			// unit 1 is the meter; unit 0 only gives the aspect ratio
+			if chunk[8] == 1 {
+				return fromPixelsPerMeter(binary.BigEndian.Uint32(chunk)), true
+			}
+			return 0, false
+		}
+		offset += 12 + length
+	}
+	return 0, false
+}
+
+// setPNGResolution adds (or replaces) the pHYs chunk of a PNG image; the
+// chunk is placed right after the IHDR chunk, which is always the first.
+func setPNGResolution(data []byte, dpi float64) []byte {
+	ppm := uint32(math.Round(dpi * inchesPerMeter))
+
+	chunk := make([]byte, 21)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], ppm)
+	binary.BigEndian.PutUint32(chunk[12:], ppm)
+	chunk[16] = 1 // the unit is the meter
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	// copy all chunks, dropping any existing pHYs
+	result := make([]byte, 0, len(data)+len(chunk))
+	result = append(result, data[:8]...)
+	for offset := 8; offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		end := min(offset+12+length, len(data))
+		kind := string(data[offset+4 : offset+8])
+		if kind != "pHYs" {
+			result = append(result, data[offset:end]...)
+		}
+		if kind == "IHDR" {
+			result = append(result, chunk...)
+		}
+		offset = end
+	}
+	return result
+}
+
+// jpegResolution reads the resolution from the JFIF APP0 segment of a JPEG
+// image or, if not available, from its EXIF APP1 segment.
+func jpegResolution(data []byte) (float64, bool) {
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xFF; {
+		marker := data[offset+1]
+		// start of scan: no more metadata segments
+		if marker == 0xDA {
+			break
+		}
+		// the length includes its own two bytes, so shorter ones are malformed
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			break
+		}
+		segment := data[offset+4 : offset+2+length]
+		switch {
+		case marker == 0xE0 && bytes.HasPrefix(segment, []byte("JFIF\x00")) && len(segment) >= 12:
+			x := float64(binary.BigEndian.Uint16(segment[8:]))
+			switch segment[7] {
+			case 1: // dots per inch
+				return x, true
+			case 2: // dots per centimeter
+				return x * centimetersPerInch, true
+			}
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			if dpi, ok := tiffResolution(segment[6:]); ok {
+				return dpi, true
+			}
+		}
+		offset += 2 + length
+	}
+	return 0, false
+}
+
+// setJPEGResolution adds a JFIF APP0 segment with the given density to a
+// JPEG image, replacing any existing one.
+func setJPEGResolution(data []byte, dpi float64) []byte {
+	density := uint16(min(math.Round(dpi), math.MaxUint16))
+
+	segment := []byte{
+		0xFF, 0xE0, // APP0 marker
+		0x00, 0x10, // segment length
+		'J', 'F', 'I', 'F', 0x00,
+		0x01, 0x02, // version 1.02
+		0x01,       // the unit is the inch
+		0x00, 0x00, // horizontal density
+		0x00, 0x00, // vertical density
+		0x00, 0x00, // no thumbnail
+	}
+	binary.BigEndian.PutUint16(segment[12:], density)
+	binary.BigEndian.PutUint16(segment[14:], density)
+
+	rest := data[2:]
+	if len(rest) >= 4 && rest[0] == 0xFF && rest[1] == 0xE0 {
+		// drop the existing APP0 segment
+		length := int(binary.BigEndian.Uint16(rest[2:]))
+		if 2+length <= len(rest) {
+			rest = rest[2+length:]
+		}
+	}
+
+	result := make([]byte, 0, len(data)+len(segment))
+	result = append(result, data[:2]...)
+	result = append(result, segment...)
+	return append(result, rest...)
+}
+
+// bmpResolution reads the resolution from the header of a BMP image.
+func bmpResolution(data []byte) (float64, bool) {
+	if len(data) < 46 {
+		return 0, false
+	}
+	ppm := binary.LittleEndian.Uint32(data[38:])
+	if ppm == 0 {
+		return 0, false
+	}
+	return fromPixelsPerMeter(ppm), true
+}
+
+// setBMPResolution sets the resolution in the header of a BMP image.
+func setBMPResolution(data []byte, dpi float64) []byte {
+	if len(data) < 46 {
+		return data
+	}
+	ppm := uint32(math.Round(dpi * inchesPerMeter))
+	binary.LittleEndian.PutUint32(data[38:], ppm)
+	binary.LittleEndian.PutUint32(data[42:], ppm)
+	return data
+}
+
+// TIFF tags and types used to read and write the resolution.
+const (
+	tiffXResolution    = 282
+	tiffYResolution    = 283
+	tiffResolutionUnit = 296
+	tiffRational       = 5
+)
+
+// ifdEntry is an entry of a TIFF Image File Directory.
+type ifdEntry struct {
+	tag, kind uint16
+	count     uint32
+	// offset is the position of the entry in the TIFF data.
+	offset int
+}
+
+// ifd reads the entries of the first Image File Directory of the given TIFF
+// data (which is also the format of EXIF metadata), along with the byte order.
+func ifd(data []byte) ([]ifdEntry, binary.ByteOrder) {
+	if len(data) < 8 {
+		return nil, nil
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, nil
+	}
+	return ifdAt(data, int(order.Uint32(data[4:])), order), order
+}
+
+// ifdAt reads the entries of the Image File Directory at the given offset.
+func ifdAt(data []byte, offset int, order binary.ByteOrder) []ifdEntry {
+	if offset <= 0 || offset+2 > len(data) {
+		return nil
+	}
+	count := int(order.Uint16(data[offset:]))
+	entries := make([]ifdEntry, 0, count)
+	for i := range count {
+		position := offset + 2 + i*12
+		if position+12 > len(data) {
+			break
+		}
+		entries = append(entries, ifdEntry{
+			tag:    order.Uint16(data[position:]),
+			kind:   order.Uint16(data[position+2:]),
+			count:  order.Uint32(data[position+4:]),
+			offset: position,
+		})
+	}
+	return entries
+}
+
+// rational returns the position of the numerator and the denominator of a
+// rational TIFF value, if valid.
+func (e ifdEntry) rational(data []byte, order binary.ByteOrder) (int, bool) {
+	if e.kind != tiffRational || e.count != 1 {
+		return 0, false
+	}
+	position := int(order.Uint32(data[e.offset+8:]))
+	if position <= 0 || position+8 > len(data) {
+		return 0, false
+	}
+	return position, true
+}
+
+// tiffResolution reads the horizontal resolution from the first Image File
+// Directory of TIFF data.
+func tiffResolution(data []byte) (float64, bool) {
+	entries, order := ifd(data)
+	var (
+		resolution float64
+		found      bool
+		unit       uint16 = 2 // the default unit is the inch
+	)
+	for _, entry := range entries {
+		switch entry.tag {
+		case tiffXResolution:
+			if position, ok := entry.rational(data, order); ok {
+				numerator := order.Uint32(data[position:])
+				denominator := order.Uint32(data[position+4:])
+				if denominator != 0 && numerator != 0 {
+					resolution, found = float64(numerator)/float64(denominator), true
+				}
+			}
+		case tiffResolutionUnit:
+			unit = order.Uint16(data[entry.offset+8:])
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	switch unit {
+	case 2: // inches
+		return resolution, true
+	case 3: // centimeters
+		return resolution * centimetersPerInch, true
+	}
+	return 0, false
+}
+
+// setTIFFResolution overwrites the resolution in the first Image File
+// Directory of TIFF data; the resolution tags must already be present.
+func setTIFFResolution(data []byte, dpi float64) []byte {
+	entries, order := ifd(data)
+	for _, entry := range entries {
+		switch entry.tag {
+		case tiffXResolution, tiffYResolution:
+			if position, ok := entry.rational(data, order); ok {
+				order.PutUint32(data[position:], uint32(math.Round(dpi*100)))
+				order.PutUint32(data[position+4:], 100)
+			}
+		case tiffResolutionUnit:
+			order.PutUint16(data[entry.offset+8:], 2) // inches
+		}
+	}
+	return data
+}
@@ -0,0 +1,358 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/gogpu/gg"
+)
+
+// ErrNoFillNoStroke is returned when a shape is neither filled nor stroked.
+var ErrNoFillNoStroke = errors.New("either fill or stroke must be specified")
+
+// ErrTooFewPoints is returned when a line or polygon has too few points.
+var ErrTooFewPoints = errors.New("too few points")
+
+// LineCap is the shape of the ends of stroked lines.
+type LineCap string
+
+const (
+	CapButt   LineCap = "butt"
+	CapRound  LineCap = "round"
+	CapSquare LineCap = "square"
+)
+
+// LineJoin is the shape of the corners where stroked segments meet.
+type LineJoin string
+
+const (
+	JoinMiter LineJoin = "miter"
+	JoinRound LineJoin = "round"
+	JoinBevel LineJoin = "bevel"
+)
+
+// Arrows selects the ends of a line that carry an arrowhead.
+type Arrows string
+
+const (
+	ArrowNone  Arrows = "none"
+	ArrowStart Arrows = "start"
+	ArrowEnd   Arrows = "end"
+	ArrowBoth  Arrows = "both"
+)
+
+// Style describes how a shape is painted.
+type Style struct {
+	// Colour is the colour used to fill or stroke the shape.
+	Colour color.Color
+	// Gradient, if not nil, is used instead of the colour; its coordinates
+	// are those of the shape.
+	Gradient *Gradient
+	// Pattern, if not nil, is used instead of the colour and the gradient.
+	Pattern *Pattern
+	// Fill is whether the shape should be filled; if false, it is stroked.
+	Fill bool
+	// Stroke is the width of the shape outline, when Fill is false.
+	Stroke float64
+	// Cap is the shape of the ends of open lines; if empty, they are butt.
+	Cap LineCap
+	// Join is the shape of the corners of the outline; if empty, they are
+	// mitered.
+	Join LineJoin
+	// Dash is the dash pattern of the outline, as alternating dash and gap
+	// lengths; if empty, the outline is solid.
+	Dash []float64
+}
+
+// paint fills or strokes the current path of the device context according
+// to the style.
+func (s Style) paint(dc *gg.Context) error {
+	s.apply(dc)
+	if s.Fill {
+		slog.Debug("filling shape", "colour", s.Colour, "gradient", s.Gradient != nil)
+		return dc.Fill()
+	} else if s.Stroke > 0 {
+		slog.Debug("stroking shape", "width", s.Stroke, "cap", s.Cap, "join", s.Join, "dash", s.Dash)
+		dc.SetStroke(s.stroke())
+		return dc.Stroke()
+	}
+	dc.ClearPath()
+	return ErrNoFillNoStroke
+}
+
+// apply sets the colour, the gradient or the pattern the shape is painted
+// with.
+func (s Style) apply(dc *gg.Context) {
+	if s.Pattern != nil {
+		dc.SetFillBrush(s.Pattern.brush(dc.GetTransform()))
+		return
+	}
+	if s.Gradient != nil {
+		dc.SetFillBrush(s.Gradient.brush(dc.GetTransform()))
+		return
+	}
+	dc.SetColor(s.Colour)
+}
+
+// stroke returns the stroke the outline of the shape is painted with; the
+// whole stroke is always set, since it is not saved along with the state of
+// the context.
+func (s Style) stroke() gg.Stroke {
+	stroke := gg.DefaultStroke().WithWidth(s.Stroke)
+	switch s.Cap {
+	case CapRound:
+		stroke.Cap = gg.LineCapRound
+	case CapSquare:
+		stroke.Cap = gg.LineCapSquare
+	}
+	switch s.Join {
+	case JoinRound:
+		stroke.Join = gg.LineJoinRound
+	case JoinBevel:
+		stroke.Join = gg.LineJoinBevel
+	}
+	if len(s.Dash) > 0 {
+		stroke.Dash = gg.NewDash(s.Dash...)
+	}
+	return stroke
+}
+
+// Rectangle returns a Painter that draws a rectangle with the top-left corner
+// at (x, y) and the given width and height; if radius is greater than zero,
+// the corners are rounded.
+func Rectangle(x, y, width, height, radius float64, style Style) Painter {
+	return func(c *Canvas) error {
+		if radius > 0 {
+			slog.Debug("drawing rounded rectangle", "x", x, "y", y, "width", width, "height", height, "radius", radius)
+			c.context.DrawRoundedRectangle(x, y, width, height, radius)
+		} else {
+			slog.Debug("drawing rectangle", "x", x, "y", y, "width", width, "height", height)
+			c.context.DrawRectangle(x, y, width, height)
+		}
+		return style.paint(c.context)
+	}
+}
+
+// Circle returns a Painter that draws a circle with the given centre and radius.
+func Circle(x, y, radius float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing circle", "x", x, "y", y, "radius", radius)
+		c.context.DrawCircle(x, y, radius)
+		return style.paint(c.context)
+	}
+}
+
+// Ellipse returns a Painter that draws an ellipse with the given centre and
+// horizontal and vertical radii.
+func Ellipse(x, y, rx, ry float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing ellipse", "x", x, "y", y, "rx", rx, "ry", ry)
+		c.context.DrawEllipse(x, y, rx, ry)
+		return style.paint(c.context)
+	}
+}
+
+// CircularArc returns a Painter that draws an arc of the circle with the given
+// centre and radius, between the two angles (in degrees, clockwise from the
+// positive x axis).
+func CircularArc(x, y, radius, from, to float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing circular arc", "x", x, "y", y, "radius", radius, "from", from, "to", to)
+		start, sweep := arcAngles(from, to)
+		c.context.MoveTo(onEllipse(x, y, radius, radius, start))
+		arc(c.context, x, y, radius, radius, start, sweep)
+		return style.paint(c.context)
+	}
+}
+
+// EllipticalArc returns a Painter that draws an arc of the ellipse with the
+// given centre and radii, between the two angles (in degrees, clockwise from
+// the positive x axis).
+func EllipticalArc(x, y, rx, ry, from, to float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing elliptical arc", "x", x, "y", y, "rx", rx, "ry", ry, "from", from, "to", to)
+		start, sweep := arcAngles(from, to)
+		c.context.MoveTo(onEllipse(x, y, rx, ry, start))
+		arc(c.context, x, y, rx, ry, start, sweep)
+		return style.paint(c.context)
+	}
+}
+
+// Sector returns a Painter that draws a pie slice, i.e. an arc of the ellipse
+// with the given centre and radii, between the two angles (in degrees,
+// clockwise from the positive x axis), closed by the radii at its ends.
+func Sector(x, y, rx, ry, from, to float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing sector", "x", x, "y", y, "rx", rx, "ry", ry, "from", from, "to", to)
+		start, sweep := arcAngles(from, to)
+		c.context.MoveTo(x, y)
+		c.context.LineTo(onEllipse(x, y, rx, ry, start))
+		arc(c.context, x, y, rx, ry, start, sweep)
+		c.context.ClosePath()
+		return style.paint(c.context)
+	}
+}
+
+// Ring returns a Painter that draws the segment of an annulus between the
+// ellipses with the given centre, outer radii (rx, ry) and inner radii (irx,
+// iry), from one angle to the other (in degrees, clockwise from the positive
+// x axis); a whole turn draws a complete ring, or donut.
+func Ring(x, y, rx, ry, irx, iry, from, to float64, style Style) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing ring", "x", x, "y", y, "rx", rx, "ry", ry, "irx", irx, "iry", iry, "from", from, "to", to)
+		start, sweep := arcAngles(from, to)
+		end := start + sweep
+		c.context.MoveTo(onEllipse(x, y, rx, ry, start))
+		arc(c.context, x, y, rx, ry, start, sweep)
+		if sweep >= 2*math.Pi {
+			// a complete ring is made of two separate outlines, so that no
+			// seam is stroked between them
+			c.context.ClosePath()
+			c.context.MoveTo(onEllipse(x, y, irx, iry, end))
+		} else {
+			c.context.LineTo(onEllipse(x, y, irx, iry, end))
+		}
+		// the inner ellipse runs the other way, which makes a hole when filled
+		arc(c.context, x, y, irx, iry, end, -sweep)
+		c.context.ClosePath()
+		return style.paint(c.context)
+	}
+}
+
+// arcAngles returns the start angle and the clockwise sweep, in radians, of an arc
+// between the two given angles, in degrees; as in DrawArc of gg, an end angle
+// lower than the start one is taken a whole turn later.
+func arcAngles(from, to float64) (float64, float64) {
+	for to < from {
+		to += 360
+	}
+	return radians(from), radians(to - from)
+}
+
+// onEllipse returns the point of the ellipse with the given centre and radii
+// at the given angle, in radians.
+func onEllipse(x, y, rx, ry, angle float64) (float64, float64) {
+	return x + rx*math.Cos(angle), y + ry*math.Sin(angle)
+}
+
+// curver is implemented by both device contexts and paths, which can be
+// extended with cubic Bézier curves.
+type curver interface {
+	CubicTo(c1x, c1y, c2x, c2y, x, y float64)
+}
+
+// arc adds to the current path, which must end at the start of the arc, an
+// arc of the ellipse with the given centre and radii, starting at the given
+// angle and sweeping the other one (in radians, clockwise if positive), as a
+// cubic Bézier curve per quarter turn at most; unlike DrawEllipticalArc of
+// gg, which only transforms the centre, the whole arc goes through the
+// transformation matrix of the context.
+func arc(dc curver, x, y, rx, ry, start, sweep float64) {
+	segments := max(1, int(math.Ceil(math.Abs(sweep)/(math.Pi/2))))
+	step := sweep / float64(segments)
+	// the distance of the control points along the tangents
+	k := 4.0 / 3.0 * math.Tan(step/4)
+	for i := range segments {
+		a1 := start + float64(i)*step
+		a2 := a1 + step
+		cos1, sin1 := math.Cos(a1), math.Sin(a1)
+		cos2, sin2 := math.Cos(a2), math.Sin(a2)
+		dc.CubicTo(
+			x+rx*(cos1-k*sin1), y+ry*(sin1+k*cos1),
+			x+rx*(cos2+k*sin2), y+ry*(sin2-k*cos2),
+			x+rx*cos2, y+ry*sin2,
+		)
+	}
+}
+
+// Polyline returns a Painter that draws a line through the given points, in
+// order, with arrowheads of the given length at the selected ends; the
+// arrowheads are filled with the colour of the style.
+func Polyline(points [][2]float64, arrows Arrows, size float64, style Style) Painter {
+	return func(c *Canvas) error {
+		if len(points) < 2 {
+			return fmt.Errorf("%w: a line needs at least 2, got %d", ErrTooFewPoints, len(points))
+		}
+		slog.Debug("drawing polyline", "points", points, "arrows", arrows, "size", size)
+		points = slices.Clone(points)
+		var heads [][3][2]float64
+		if arrows == ArrowStart || arrows == ArrowBoth {
+			heads = append(heads, arrowhead(points, size))
+		}
+		if arrows == ArrowEnd || arrows == ArrowBoth {
+			slices.Reverse(points)
+			heads = append(heads, arrowhead(points, size))
+			slices.Reverse(points)
+		}
+
+		c.context.MoveTo(points[0][0], points[0][1])
+		for _, p := range points[1:] {
+			c.context.LineTo(p[0], p[1])
+		}
+		if err := style.paint(c.context); err != nil {
+			return err
+		}
+		for _, head := range heads {
+			c.context.MoveTo(head[0][0], head[0][1])
+			c.context.LineTo(head[1][0], head[1][1])
+			c.context.LineTo(head[2][0], head[2][1])
+			c.context.ClosePath()
+		}
+		if len(heads) > 0 {
+			style.apply(c.context)
+			return c.context.Fill()
+		}
+		return nil
+	}
+}
+
+// arrowhead returns the corners of an arrowhead of the given length, with
+// its tip at the first of the given points and pointing away from the next
+// distinct one; the first point is moved back to the base of the arrowhead,
+// so that the end of the line does not stick out of it.
+func arrowhead(points [][2]float64, size float64) [3][2]float64 {
+	tip := points[0]
+	for _, p := range points[1:] {
+		dx, dy := tip[0]-p[0], tip[1]-p[1]
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		dx, dy = dx/length, dy/length
+		// the line stops at the base of the arrowhead, unless the segment is
+		// shorter than the arrowhead
+		back := min(size, length)
+		points[0] = [2]float64{tip[0] - dx*back, tip[1] - dy*back}
+		bx, by := tip[0]-dx*size, tip[1]-dy*size
+		half := size * 0.4
+		return [3][2]float64{tip, {bx - dy*half, by + dx*half}, {bx + dy*half, by - dx*half}}
+	}
+	// all points coincide: there is no direction to point to
+	return [3][2]float64{tip, tip, tip}
+}
+
+// Polygon returns a Painter that draws the closed polygon with the given
+// vertices.
+func Polygon(points [][2]float64, style Style) Painter {
+	return func(c *Canvas) error {
+		if len(points) < 3 {
+			return fmt.Errorf("%w: a polygon needs at least 3, got %d", ErrTooFewPoints, len(points))
+		}
+		slog.Debug("drawing polygon", "points", points)
+		c.context.MoveTo(points[0][0], points[0][1])
+		for _, p := range points[1:] {
+			c.context.LineTo(p[0], p[1])
+		}
+		c.context.ClosePath()
+		return style.paint(c.context)
+	}
+}
+
+// radians converts an angle from degrees to radians.
+func radians(degrees float64) float64 {
+	return degrees / 180 * math.Pi
+}
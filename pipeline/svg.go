@@ -0,0 +1,320 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSVG is returned when an SVG document cannot be parsed.
+var ErrInvalidSVG = errors.New("invalid SVG document")
+
+// SVG is a parsed SVG document, which is drawn as vector graphics, and thus
+// stays crisp, at any size.
+//
+// A practical subset of SVG 1.1 is supported: the path, rect, circle,
+// ellipse, line, polyline, polygon and text (with tspan) elements; groups,
+// nested svg elements and the use of elements and symbols; transforms;
+// fills and strokes with solid colours or linear and radial gradients;
+// opacity; presentation attributes, style attributes and <style> rules with
+// element, class and id selectors. Clipping paths, masks, filters, patterns,
+// markers and embedded images are ignored.
+type SVG struct {
+	root *svgNode
+	// ids maps the identifiers of the elements to the elements.
+	ids map[string]*svgNode
+}
+
+// svgNode is an element of an SVG document, or a piece of text within one
+// if its name is empty.
+type svgNode struct {
+	name     string
+	attrs    map[string]string
+	children []*svgNode
+	text     string
+}
+
+// IsSVG returns whether the given file is an SVG document, judging from its
+// extension.
+func IsSVG(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".svg")
+}
+
+// LoadSVG reads and parses the SVG document in the given file.
+func LoadSVG(path string) (*SVG, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSVG(data)
+}
+
+// ParseSVG parses an SVG document.
+func ParseSVG(data []byte) (*SVG, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		root  *svgNode
+		stack []*svgNode
+		css   strings.Builder
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSVG, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &svgNode{name: t.Name.Local, attrs: map[string]string{}}
+			for _, attr := range t.Attr {
+				// namespaces are ignored, so that xlink:href is the same as href
+				n.attrs[attr.Name.Local] = strings.TrimSpace(attr.Value)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			switch parent := stack[len(stack)-1]; parent.name {
+			case "style":
+				css.Write(t)
+			case "text", "tspan":
+				parent.children = append(parent.children, &svgNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil || root.name != "svg" {
+		return nil, fmt.Errorf("%w: no svg element", ErrInvalidSVG)
+	}
+
+	s := &SVG{root: root, ids: map[string]*svgNode{}}
+	rules := parseCSS(css.String())
+	var walk func(n *svgNode)
+	walk = func(n *svgNode) {
+		if n.name == "" {
+			return
+		}
+		// style rules override presentation attributes, and are overridden
+		// by style attributes
+		for _, rule := range rules {
+			if rule.matches(n) {
+				for name, value := range rule.declarations {
+					n.attrs[name] = value
+				}
+			}
+		}
+		for name, value := range parseDeclarations(n.attrs["style"]) {
+			n.attrs[name] = value
+		}
+		if id := n.attrs["id"]; id != "" {
+			s.ids[id] = n
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(root)
+	slog.Debug("SVG document parsed", "elements", len(s.ids), "rules", len(rules))
+	return s, nil
+}
+
+// Size returns the natural size of the drawing, in pixels, given by the width
+// and height of its root element or, failing those, by its view box; as in
+// browsers, it is 300 by 150 pixels if neither is given.
+func (s *SVG) Size() (float64, float64) {
+	width, hasWidth := svgLength(s.root.attrs["width"], 0, 16)
+	height, hasHeight := svgLength(s.root.attrs["height"], 0, 16)
+	hasWidth = hasWidth && !strings.HasSuffix(s.root.attrs["width"], "%")
+	hasHeight = hasHeight && !strings.HasSuffix(s.root.attrs["height"], "%")
+	box, ok := parseViewBox(s.root.attrs["viewBox"])
+	switch {
+	case hasWidth && hasHeight:
+		return width, height
+	case ok && hasWidth:
+		return width, width * box[3] / box[2]
+	case ok && hasHeight:
+		return height * box[2] / box[3], height
+	case ok:
+		return box[2], box[3]
+	}
+	return 300, 150
+}
+
+// cssRule is a rule of a style sheet, with a single simple selector.
+type cssRule struct {
+	tag          string
+	id           string
+	classes      []string
+	specificity  int
+	declarations map[string]string
+}
+
+// cssSelector matches the simple selectors supported in style sheets, i.e.
+// an optional element name followed by classes and identifiers.
+var cssSelector = regexp.MustCompile(`^([a-zA-Z][\w-]*|\*)?((?:[.#][\w-]+)*)$`)
+
+// cssPart matches the classes and identifiers of a simple selector.
+var cssPart = regexp.MustCompile(`[.#][\w-]+`)
+
+// cssComment matches the comments in style sheets.
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseCSS parses the rules of a style sheet, sorted by ascending
+// specificity; rules with unsupported selectors and at-rules are skipped.
+func parseCSS(sheet string) []cssRule {
+	var rules []cssRule
+	sheet = cssComment.ReplaceAllString(sheet, "")
+	for _, block := range strings.Split(sheet, "}") {
+		selectors, body, ok := strings.Cut(block, "{")
+		selectors = strings.TrimSpace(selectors)
+		if !ok || strings.HasPrefix(selectors, "@") {
+			continue
+		}
+		declarations := parseDeclarations(body)
+		for _, selector := range strings.Split(selectors, ",") {
+			selector = strings.TrimSpace(selector)
+			match := cssSelector.FindStringSubmatch(selector)
+			if selector == "" || match == nil {
+				slog.Debug("unsupported CSS selector", "selector", selector)
+				continue
+			}
+			rule := cssRule{tag: match[1], declarations: declarations}
+			if rule.tag != "" && rule.tag != "*" {
+				rule.specificity = 1
+			}
+			for _, part := range cssPart.FindAllString(match[2], -1) {
+				if part[0] == '#' {
+					rule.id = part[1:]
+					rule.specificity += 100
+				} else {
+					rule.classes = append(rule.classes, part[1:])
+					rule.specificity += 10
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortStableFunc(rules, func(a, b cssRule) int {
+		return a.specificity - b.specificity
+	})
+	return rules
+}
+
+// matches returns whether the rule applies to the given element.
+func (r cssRule) matches(n *svgNode) bool {
+	if r.tag != "" && r.tag != "*" && r.tag != n.name {
+		return false
+	}
+	if r.id != "" && r.id != n.attrs["id"] {
+		return false
+	}
+	classes := strings.Fields(n.attrs["class"])
+	for _, class := range r.classes {
+		if !slices.Contains(classes, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDeclarations parses a list of CSS declarations, such as the value of
+// a style attribute, into a map of properties.
+func parseDeclarations(s string) map[string]string {
+	declarations := map[string]string{}
+	for _, declaration := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		declarations[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+	return declarations
+}
+
+// svgNumbers matches the numbers in lists of coordinates and arguments.
+var svgNumbers = regexp.MustCompile(`[-+]?(?:\d*\.\d+|\d+\.?)(?:[eE][-+]?\d+)?`)
+
+// parseNumbers returns the numbers in a list separated by commas and/or
+// whitespace, such as the points of a polygon.
+func parseNumbers(s string) []float64 {
+	var numbers []float64
+	for _, match := range svgNumbers.FindAllString(s, -1) {
+		if n, err := strconv.ParseFloat(match, 64); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// parseViewBox parses the view box of an element, as its minimum x and y,
+// width and height.
+func parseViewBox(s string) ([4]float64, bool) {
+	var box [4]float64
+	numbers := parseNumbers(s)
+	if len(numbers) != 4 || numbers[2] <= 0 || numbers[3] <= 0 {
+		return box, false
+	}
+	copy(box[:], numbers)
+	return box, true
+}
+
+// svgUnits are the sizes of the absolute units of length, in pixels.
+var svgUnits = map[string]float64{
+	"px": 1,
+	"pt": 96.0 / 72.0,
+	"pc": 16,
+	"mm": 96 / 25.4,
+	"cm": 96 / 2.54,
+	"in": 96,
+}
+
+// svgLength parses a length, with an optional unit; percentages refer to the
+// given reference, and em and ex units to the given font size.
+func svgLength(s string, reference, fontSize float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(s, "%"):
+		s, scale = strings.TrimSuffix(s, "%"), reference/100
+	case strings.HasSuffix(s, "em"):
+		s, scale = strings.TrimSuffix(s, "em"), fontSize
+	case strings.HasSuffix(s, "ex"):
+		s, scale = strings.TrimSuffix(s, "ex"), fontSize/2
+	case len(s) > 2:
+		if unit, ok := svgUnits[s[len(s)-2:]]; ok {
+			s, scale = s[:len(s)-2], unit
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v * scale, true
+}
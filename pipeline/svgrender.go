@@ -0,0 +1,890 @@
+package pipeline
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"maps"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gogpu/gg"
+	"golang.org/x/image/colornames"
+)
+
+// SVGImage returns a Painter that draws an SVG document into the box with the
+// top-left corner at (x, y) and the given size; the view box of the document
+// is fitted into the box according to its preserveAspectRatio attribute.
+func SVGImage(s *SVG, x, y, width, height float64) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("drawing SVG document", "x", x, "y", y, "width", width, "height", height)
+		r := &svgRenderer{canvas: c, svg: s, fonts: map[string]*Font{}}
+		c.context.Push()
+		defer c.context.Pop()
+		c.context.Translate(x, y)
+		r.viewport(s.root, width, height)
+		r.children(s.root, svgInitial, 0)
+		return r.err
+	}
+}
+
+// svgRenderer holds the state of the rendering of an SVG document.
+type svgRenderer struct {
+	canvas *Canvas
+	svg    *SVG
+	// width and height are the size of the current viewport, in user units,
+	// which percentages refer to.
+	width, height float64
+	// fonts caches the fonts used by text elements, by family and style.
+	fonts map[string]*Font
+	// nodes is the number of elements rendered so far.
+	nodes int
+	// err is the first error met while painting.
+	err error
+}
+
+// svgProperties are the values of the properties inherited by elements from
+// their parents.
+type svgProperties map[string]string
+
+// svgInitial are the initial values of the inherited properties.
+var svgInitial = svgProperties{
+	"fill":              "black",
+	"fill-opacity":      "1",
+	"fill-rule":         "nonzero",
+	"stroke":            "none",
+	"stroke-width":      "1",
+	"stroke-opacity":    "1",
+	"stroke-linecap":    "butt",
+	"stroke-linejoin":   "miter",
+	"stroke-miterlimit": "4",
+	"stroke-dasharray":  "none",
+	"color":             "black",
+	"font-family":       "sans-serif",
+	"font-size":         "16",
+	"font-weight":       "normal",
+	"font-style":        "normal",
+	"text-anchor":       "start",
+	"letter-spacing":    "normal",
+	"visibility":        "visible",
+}
+
+// svgMaxDepth limits the nesting of elements, including those reached
+// through use elements, which could otherwise refer to each other forever.
+const svgMaxDepth = 64
+
+// svgMaxNodes limits the number of elements rendered, since use elements
+// referring to groups of use elements multiply them at every level, so that
+// a small document could otherwise take forever to render.
+const svgMaxNodes = 100000
+
+// inherit returns the properties of an element, given those of its parent.
+func (r *svgRenderer) inherit(n *svgNode, parent svgProperties) svgProperties {
+	properties := maps.Clone(parent)
+	for name := range svgInitial {
+		value, ok := n.attrs[name]
+		if !ok || value == "inherit" {
+			continue
+		}
+		if name == "font-size" {
+			// relative sizes refer to the size of the parent
+			size := r.number(parent["font-size"], 16)
+			if v, ok := svgLength(value, size, size); ok {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			} else {
+				continue
+			}
+		}
+		properties[name] = value
+	}
+	return properties
+}
+
+// children renders the children of an element.
+func (r *svgRenderer) children(n *svgNode, properties svgProperties, depth int) {
+	for _, child := range n.children {
+		r.render(child, properties, depth+1)
+	}
+}
+
+// render renders an element and its children.
+func (r *svgRenderer) render(n *svgNode, parent svgProperties, depth int) {
+	if n.name == "" || depth > svgMaxDepth || n.attrs["display"] == "none" {
+		return
+	}
+	if r.nodes++; r.nodes > svgMaxNodes {
+		r.check(fmt.Errorf("%w: more than %d elements to render", ErrInvalidSVG, svgMaxNodes))
+		return
+	}
+	properties := r.inherit(n, parent)
+	dc := r.canvas.context
+	dc.Push()
+	defer dc.Pop()
+	if transform, ok := n.attrs["transform"]; ok {
+		dc.Transform(parseTransform(transform))
+	}
+	if opacity := r.number(n.attrs["opacity"], 1); opacity < 1 {
+		// the element is composited as a whole
+		dc.PushLayer(gg.BlendNormal, opacity)
+		defer dc.PopLayer()
+	}
+
+	switch n.name {
+	case "g", "a", "switch":
+		r.children(n, properties, depth)
+	case "svg":
+		width, height := r.width, r.height
+		defer func() { r.width, r.height = width, height }()
+		dc.Translate(r.x(n.attrs["x"], properties), r.y(n.attrs["y"], properties))
+		r.viewport(n, r.x(attr(n, "width", "100%"), properties), r.y(attr(n, "height", "100%"), properties))
+		r.children(n, properties, depth)
+	case "use":
+		target, ok := r.svg.ids[strings.TrimPrefix(n.attrs["href"], "#")]
+		if !ok {
+			slog.Debug("SVG use element refers to an unknown element", "href", n.attrs["href"])
+			return
+		}
+		dc.Translate(r.x(n.attrs["x"], properties), r.y(n.attrs["y"], properties))
+		if target.name == "symbol" {
+			width, height := r.width, r.height
+			defer func() { r.width, r.height = width, height }()
+			r.viewport(target, r.x(attr(n, "width", "100%"), properties), r.y(attr(n, "height", "100%"), properties))
+			r.children(target, r.inherit(target, properties), depth+1)
+		} else {
+			r.render(target, properties, depth+1)
+		}
+	case "path":
+		path, err := gg.ParseSVGPath(n.attrs["d"])
+		if err != nil {
+			slog.Debug("invalid SVG path data", "d", n.attrs["d"], "error", err)
+		}
+		// as in browsers, the path is drawn up to the error
+		r.shape(path, properties)
+	case "rect", "circle", "ellipse", "line", "polyline", "polygon":
+		r.shape(r.basic(n, properties), properties)
+	case "text":
+		r.text(n, properties)
+	case "defs", "symbol", "style", "title", "desc", "metadata", "linearGradient", "radialGradient":
+		// these are only rendered by reference, if at all
+	default:
+		slog.Debug("unsupported SVG element", "name", n.name)
+	}
+}
+
+// attr returns the value of an attribute of an element, or the given default
+// value if it is missing.
+func attr(n *svgNode, name, value string) string {
+	if v, ok := n.attrs[name]; ok {
+		return v
+	}
+	return value
+}
+
+// viewport maps the view box of an element (svg or symbol) onto a viewport of
+// the given size at the origin, according to its preserveAspectRatio
+// attribute, and makes it the reference for percentages.
+func (r *svgRenderer) viewport(n *svgNode, width, height float64) {
+	r.width, r.height = width, height
+	box, ok := parseViewBox(n.attrs["viewBox"])
+	if !ok {
+		return
+	}
+	sx, sy := width/box[2], height/box[3]
+	fields := strings.Fields(attr(n, "preserveAspectRatio", "xMidYMid meet"))
+	align := "xMidYMid"
+	if len(fields) > 0 {
+		align = fields[0]
+	}
+	if align != "none" {
+		if len(fields) > 1 && fields[1] == "slice" {
+			sx = max(sx, sy)
+		} else {
+			sx = min(sx, sy)
+		}
+		sy = sx
+	}
+	offset := func(position string, space float64) float64 {
+		switch position {
+		case "Mid":
+			return space / 2
+		case "Max":
+			return space
+		}
+		return 0
+	}
+	tx, ty := 0.0, 0.0
+	if len(align) == 8 {
+		tx = offset(align[1:4], width-box[2]*sx)
+		ty = offset(align[5:8], height-box[3]*sy)
+	}
+	dc := r.canvas.context
+	dc.Translate(tx, ty)
+	dc.Scale(sx, sy)
+	dc.Translate(-box[0], -box[1])
+	r.width, r.height = box[2], box[3]
+}
+
+// number parses a plain number, or a percentage, returning the default value
+// if the string is empty or invalid.
+func (r *svgRenderer) number(s string, value float64) float64 {
+	if strings.HasSuffix(s, "%") {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64); err == nil {
+			return v / 100
+		}
+		return value
+	}
+	if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return v
+	}
+	return value
+}
+
+// length parses a length, whose percentages refer to the given reference.
+func (r *svgRenderer) length(s string, reference float64, properties svgProperties) float64 {
+	v, _ := svgLength(s, reference, r.number(properties["font-size"], 16))
+	return v
+}
+
+// x parses a horizontal coordinate or length.
+func (r *svgRenderer) x(s string, properties svgProperties) float64 {
+	return r.length(s, r.width, properties)
+}
+
+// y parses a vertical coordinate or length.
+func (r *svgRenderer) y(s string, properties svgProperties) float64 {
+	return r.length(s, r.height, properties)
+}
+
+// diagonal parses a length which is neither horizontal nor vertical, such as
+// a radius, whose percentages refer to the normalised diagonal of the
+// viewport.
+func (r *svgRenderer) diagonal(s string, properties svgProperties) float64 {
+	return r.length(s, math.Hypot(r.width, r.height)/math.Sqrt2, properties)
+}
+
+// basic returns the outline of a basic shape, or nil if it is not rendered.
+func (r *svgRenderer) basic(n *svgNode, properties svgProperties) *gg.Path {
+	path := gg.NewPath()
+	switch n.name {
+	case "rect":
+		x, y := r.x(n.attrs["x"], properties), r.y(n.attrs["y"], properties)
+		width, height := r.x(n.attrs["width"], properties), r.y(n.attrs["height"], properties)
+		if width <= 0 || height <= 0 {
+			return nil
+		}
+		rx, hasRX := n.attrs["rx"]
+		ry, hasRY := n.attrs["ry"]
+		if !hasRX {
+			rx = ry
+		}
+		if !hasRY {
+			ry = rx
+		}
+		radiusX := min(r.x(rx, properties), width/2)
+		radiusY := min(r.y(ry, properties), height/2)
+		if radiusX <= 0 || radiusY <= 0 {
+			path.Rectangle(x, y, width, height)
+			break
+		}
+		path.MoveTo(x+radiusX, y)
+		path.LineTo(x+width-radiusX, y)
+		arc(path, x+width-radiusX, y+radiusY, radiusX, radiusY, -math.Pi/2, math.Pi/2)
+		path.LineTo(x+width, y+height-radiusY)
+		arc(path, x+width-radiusX, y+height-radiusY, radiusX, radiusY, 0, math.Pi/2)
+		path.LineTo(x+radiusX, y+height)
+		arc(path, x+radiusX, y+height-radiusY, radiusX, radiusY, math.Pi/2, math.Pi/2)
+		path.LineTo(x, y+radiusY)
+		arc(path, x+radiusX, y+radiusY, radiusX, radiusY, math.Pi, math.Pi/2)
+		path.Close()
+	case "circle":
+		radius := r.diagonal(n.attrs["r"], properties)
+		if radius <= 0 {
+			return nil
+		}
+		path.Ellipse(r.x(n.attrs["cx"], properties), r.y(n.attrs["cy"], properties), radius, radius)
+	case "ellipse":
+		rx, ry := r.x(n.attrs["rx"], properties), r.y(n.attrs["ry"], properties)
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		path.Ellipse(r.x(n.attrs["cx"], properties), r.y(n.attrs["cy"], properties), rx, ry)
+	case "line":
+		path.MoveTo(r.x(n.attrs["x1"], properties), r.y(n.attrs["y1"], properties))
+		path.LineTo(r.x(n.attrs["x2"], properties), r.y(n.attrs["y2"], properties))
+	case "polyline", "polygon":
+		points := parseNumbers(n.attrs["points"])
+		if len(points) < 4 {
+			return nil
+		}
+		path.MoveTo(points[0], points[1])
+		for i := 2; i+1 < len(points); i += 2 {
+			path.LineTo(points[i], points[i+1])
+		}
+		if n.name == "polygon" {
+			path.Close()
+		}
+	}
+	return path
+}
+
+// shape fills and strokes an outline, in user units, according to the given
+// properties.
+func (r *svgRenderer) shape(path *gg.Path, properties svgProperties) {
+	if path == nil || path.NumVerbs() == 0 || properties["visibility"] != "visible" {
+		return
+	}
+	dc := r.canvas.context
+	if fill := r.brush(properties["fill"], r.number(properties["fill-opacity"], 1), path, properties); fill != nil {
+		dc.DrawPath(path)
+		dc.SetFillBrush(fill)
+		if properties["fill-rule"] == "evenodd" {
+			dc.SetFillRule(gg.FillRuleEvenOdd)
+		}
+		r.check(dc.Fill())
+		dc.SetFillRule(gg.FillRuleNonZero)
+	}
+	if stroke := r.brush(properties["stroke"], r.number(properties["stroke-opacity"], 1), path, properties); stroke != nil {
+		width := r.diagonal(properties["stroke-width"], properties)
+		if width <= 0 {
+			return
+		}
+		style := gg.DefaultStroke().WithWidth(width).WithMiterLimit(r.number(properties["stroke-miterlimit"], 4))
+		switch properties["stroke-linecap"] {
+		case "round":
+			style.Cap = gg.LineCapRound
+		case "square":
+			style.Cap = gg.LineCapSquare
+		}
+		switch properties["stroke-linejoin"] {
+		case "round":
+			style.Join = gg.LineJoinRound
+		case "bevel":
+			style.Join = gg.LineJoinBevel
+		}
+		if dashes := parseNumbers(properties["stroke-dasharray"]); len(dashes) > 0 && properties["stroke-dasharray"] != "none" {
+			if len(dashes)%2 == 1 {
+				dashes = append(dashes, dashes...)
+			}
+			// the canvas scales the stroke along with the outline, but only
+			// enlarges the dashes, so they are shrunk here
+			scale := min(dc.GetTransform().ScaleFactor(), 1)
+			total := 0.0
+			for i := range dashes {
+				dashes[i] *= scale
+				total += dashes[i]
+			}
+			if total > 0 {
+				style.Dash = gg.NewDash(dashes...)
+			}
+		}
+		dc.DrawPath(path)
+		dc.SetStroke(style)
+		dc.SetStrokeBrush(stroke)
+		r.check(dc.Stroke())
+	}
+}
+
+// check records the first error met while painting.
+func (r *svgRenderer) check(err error) {
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// brush returns the brush painting with the given value of a fill or stroke
+// property (a colour, or a reference to a gradient with an optional fallback
+// colour), made more transparent by the given opacity, or nil if nothing is
+// painted; the outline is used by gradients relative to its bounding box.
+func (r *svgRenderer) brush(value string, opacity float64, path *gg.Path, properties svgProperties) gg.Brush {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "url(") {
+		reference, fallback, _ := strings.Cut(strings.TrimPrefix(value, "url("), ")")
+		reference = strings.Trim(strings.TrimSpace(reference), `"'`)
+		if gradient, m, ok := r.gradient(strings.TrimPrefix(reference, "#"), opacity, path, properties); ok {
+			return gradient.brush(m)
+		}
+		value = strings.TrimSpace(fallback)
+	}
+	colour, ok := r.colour(value, properties)
+	if !ok {
+		return nil
+	}
+	rgba := gg.FromColor(colour)
+	rgba.A *= min(max(opacity, 0), 1)
+	return gg.Solid(rgba)
+}
+
+// colour parses a colour, returning false if it is none or invalid.
+func (r *svgRenderer) colour(value string, properties svgProperties) (color.Color, bool) {
+	value = strings.TrimSpace(value)
+	if value == "currentColor" {
+		value = properties["color"]
+	}
+	colour, ok := parseSVGColour(value)
+	if !ok && value != "none" && value != "" {
+		slog.Debug("unsupported SVG colour", "value", value)
+	}
+	return colour, ok
+}
+
+// svgFunction matches the functional notation of colours and transforms.
+var svgFunction = regexp.MustCompile(`([a-zA-Z]+)\s*\(([^)]*)\)`)
+
+// parseSVGColour parses a colour in hexadecimal, rgb() or rgba() notation,
+// or given by name.
+func parseSVGColour(value string) (color.Color, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case value == "" || value == "none":
+		return nil, false
+	case value == "transparent":
+		return color.NRGBA{}, true
+	case strings.HasPrefix(value, "#"):
+		colour, err := ParseColour(value)
+		return colour, err == nil
+	case strings.HasPrefix(value, "rgb"):
+		match := svgFunction.FindStringSubmatch(value)
+		if match == nil {
+			return nil, false
+		}
+		parts := strings.FieldsFunc(match[2], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return nil, false
+		}
+		var channels [4]uint8
+		channels[3] = 255
+		for i, part := range parts[:min(len(parts), 4)] {
+			scale := 1.0
+			if i == 3 {
+				scale = 255
+			}
+			if strings.HasSuffix(part, "%") {
+				part, scale = strings.TrimSuffix(part, "%"), 2.55
+			}
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, false
+			}
+			channels[i] = uint8(math.Round(min(max(v*scale, 0), 255)))
+		}
+		return color.NRGBA{R: channels[0], G: channels[1], B: channels[2], A: channels[3]}, true
+	}
+	colour, ok := colornames.Map[value]
+	return colour, ok
+}
+
+// parseTransform parses the value of a transform attribute, i.e. a list of
+// matrix, translate, scale, rotate, skewX and skewY functions; unknown
+// functions are ignored.
+func parseTransform(s string) gg.Matrix {
+	m := gg.Identity()
+	for _, match := range svgFunction.FindAllStringSubmatch(s, -1) {
+		args := parseNumbers(match[2])
+		arg := func(i int, value float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return value
+		}
+		var t gg.Matrix
+		switch match[1] {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			t = gg.Matrix{A: args[0], B: args[2], C: args[4], D: args[1], E: args[3], F: args[5]}
+		case "translate":
+			t = gg.Translate(arg(0, 0), arg(1, 0))
+		case "scale":
+			t = gg.Scale(arg(0, 1), arg(1, arg(0, 1)))
+		case "rotate":
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = gg.Translate(cx, cy).Multiply(gg.Rotate(radians(arg(0, 0)))).Multiply(gg.Translate(-cx, -cy))
+		case "skewX":
+			t = gg.Matrix{A: 1, B: math.Tan(radians(arg(0, 0))), E: 1}
+		case "skewY":
+			t = gg.Matrix{A: 1, D: math.Tan(radians(arg(0, 0))), E: 1}
+		default:
+			slog.Debug("unsupported SVG transform", "function", match[1])
+			continue
+		}
+		m = m.Multiply(t)
+	}
+	return m
+}
+
+// gradient returns the gradient with the given identifier, made more
+// transparent by the given opacity, along with the matrix mapping its
+// coordinates to those of the image; gradients relative to the bounding box
+// of the shape are fitted to the given outline.
+func (r *svgRenderer) gradient(id string, opacity float64, path *gg.Path, properties svgProperties) (Gradient, gg.Matrix, bool) {
+	n, ok := r.svg.ids[id]
+	if !ok || (n.name != "linearGradient" && n.name != "radialGradient") {
+		slog.Debug("SVG paint refers to an unknown gradient", "id", id)
+		return Gradient{}, gg.Matrix{}, false
+	}
+	// gradients inherit the attributes and stops they lack from the
+	// gradients they refer to
+	chain := []*svgNode{n}
+	for len(chain) < svgMaxDepth {
+		next, ok := r.svg.ids[strings.TrimPrefix(chain[len(chain)-1].attrs["href"], "#")]
+		if !ok {
+			break
+		}
+		chain = append(chain, next)
+	}
+	get := func(name, value string) string {
+		for _, g := range chain {
+			if v, ok := g.attrs[name]; ok {
+				return v
+			}
+		}
+		return value
+	}
+
+	m := r.canvas.context.GetTransform()
+	bounding := get("gradientUnits", "objectBoundingBox") != "userSpaceOnUse"
+	coordinate := func(name, value string, reference float64) float64 {
+		s := get(name, value)
+		if bounding {
+			return r.number(s, 0)
+		}
+		return r.length(s, reference, properties)
+	}
+	if bounding {
+		left, top, width, height := bounds(path)
+		if width <= 0 || height <= 0 {
+			return Gradient{}, gg.Matrix{}, false
+		}
+		m = m.Multiply(gg.Translate(left, top)).Multiply(gg.Scale(width, height))
+	}
+	if transform, ok := n.attrs["gradientTransform"]; ok {
+		m = m.Multiply(parseTransform(transform))
+	} else {
+		m = m.Multiply(parseTransform(get("gradientTransform", "")))
+	}
+
+	g := Gradient{Kind: LinearGradient, Spread: SpreadPad}
+	switch get("spreadMethod", "pad") {
+	case "repeat":
+		g.Spread = SpreadRepeat
+	case "reflect":
+		g.Spread = SpreadReflect
+	}
+	diagonal := math.Hypot(r.width, r.height) / math.Sqrt2
+	if n.name == "radialGradient" {
+		g.Kind = RadialGradient
+		g.CX = coordinate("cx", "50%", r.width)
+		g.CY = coordinate("cy", "50%", r.height)
+		g.Radius = coordinate("r", "50%", diagonal)
+		g.FX = coordinate("fx", get("cx", "50%"), r.width)
+		g.FY = coordinate("fy", get("cy", "50%"), r.height)
+	} else {
+		g.X1 = coordinate("x1", "0%", r.width)
+		g.Y1 = coordinate("y1", "0%", r.height)
+		g.X2 = coordinate("x2", "100%", r.width)
+		g.Y2 = coordinate("y2", "0%", r.height)
+	}
+
+	for _, candidate := range chain {
+		offset := 0.0
+		for _, stop := range candidate.children {
+			if stop.name != "stop" {
+				continue
+			}
+			offset = max(offset, min(max(r.number(stop.attrs["offset"], 0), 0), 1))
+			colour, ok := r.colour(attr(stop, "stop-color", "black"), svgProperties{"color": attr(stop, "color", properties["color"])})
+			if !ok {
+				colour = color.NRGBA{}
+			}
+			rgba := gg.FromColor(colour)
+			rgba.A *= min(max(r.number(stop.attrs["stop-opacity"], 1)*opacity, 0), 1)
+			g.Stops = append(g.Stops, GradientStop{Offset: offset, Colour: rgba})
+		}
+		if len(g.Stops) > 0 {
+			break
+		}
+	}
+	return g, m, true
+}
+
+// bounds returns the top-left corner and the size of the box enclosing the
+// points of a path, including the control points of its curves.
+func bounds(path *gg.Path) (float64, float64, float64, float64) {
+	coords := path.Coords()
+	if len(coords) < 2 {
+		return 0, 0, 0, 0
+	}
+	left, top, right, bottom := coords[0], coords[1], coords[0], coords[1]
+	for i := 2; i+1 < len(coords); i += 2 {
+		left, right = min(left, coords[i]), max(right, coords[i])
+		top, bottom = min(top, coords[i+1]), max(bottom, coords[i+1])
+	}
+	return left, top, right - left, bottom - top
+}
+
+// svgSpaces matches runs of whitespace in text.
+var svgSpaces = regexp.MustCompile(`\s+`)
+
+// svgPiece is a piece of text with the same properties, within a text
+// element.
+type svgPiece struct {
+	text       string
+	properties svgProperties
+	// x and y are the absolute position of the piece, if given; dx and dy
+	// move it relative to the end of the previous one.
+	x, y   *float64
+	dx, dy float64
+}
+
+// text renders a text element: its pieces are laid out one after the other,
+// starting a new chunk wherever an absolute position is given, and each
+// chunk is aligned according to the text-anchor property of its first piece.
+func (r *svgRenderer) text(n *svgNode, properties svgProperties) {
+	var (
+		pieces  []svgPiece
+		pending svgPiece
+		space   = true
+	)
+	var collect func(n *svgNode, properties svgProperties, depth int)
+	collect = func(n *svgNode, properties svgProperties, depth int) {
+		if xs := parseNumbers(n.attrs["x"]); len(xs) > 0 {
+			pending.x = &xs[0]
+		}
+		if ys := parseNumbers(n.attrs["y"]); len(ys) > 0 {
+			pending.y = &ys[0]
+		}
+		if dxs := parseNumbers(n.attrs["dx"]); len(dxs) > 0 {
+			pending.dx += dxs[0]
+		}
+		if dys := parseNumbers(n.attrs["dy"]); len(dys) > 0 {
+			pending.dy += dys[0]
+		}
+		for _, child := range n.children {
+			switch {
+			case child.name == "":
+				// whitespace is collapsed, as with xml:space="default"
+				text := svgSpaces.ReplaceAllString(child.text, " ")
+				if strings.HasPrefix(text, " ") && space {
+					text = text[1:]
+				}
+				if text == "" {
+					continue
+				}
+				space = strings.HasSuffix(text, " ")
+				piece := pending
+				piece.text, piece.properties = text, properties
+				pieces = append(pieces, piece)
+				pending = svgPiece{}
+			case child.name == "tspan" && child.attrs["display"] != "none" && depth < svgMaxDepth:
+				collect(child, r.inherit(child, properties), depth+1)
+			}
+		}
+	}
+	collect(n, properties, 0)
+	if len(pieces) == 0 {
+		return
+	}
+	last := &pieces[len(pieces)-1]
+	last.text = strings.TrimSuffix(last.text, " ")
+
+	// lay the pieces out
+	type placed struct {
+		style TextStyle
+		x, y  float64
+		chunk int
+	}
+	var (
+		layout []placed
+		ends   []float64
+		starts []float64
+		x, y   float64
+	)
+	for i, piece := range pieces {
+		if i == 0 || piece.x != nil || piece.y != nil {
+			if piece.x != nil {
+				x = *piece.x
+			}
+			if piece.y != nil {
+				y = *piece.y
+			}
+			starts = append(starts, x+piece.dx)
+			ends = append(ends, x+piece.dx)
+		}
+		x, y = x+piece.dx, y+piece.dy
+		style, err := r.textStyle(piece.properties)
+		if err != nil {
+			r.check(err)
+			return
+		}
+		// spaces at the ends of lines are not measured, so those at the ends
+		// of the pieces are measured apart and the pieces trimmed
+		text := strings.Trim(piece.text, " ")
+		leading, trailing := strings.HasPrefix(piece.text, " "), strings.HasSuffix(piece.text, " ")
+		space := 0.0
+		if leading || trailing {
+			if space, err = spaceWidth(style); err != nil {
+				r.check(err)
+				return
+			}
+		}
+		if leading {
+			x += space
+		}
+		extents, err := MeasureText(text, style)
+		if err != nil {
+			r.check(err)
+			return
+		}
+		pieces[i].text = text
+		layout = append(layout, placed{style: style, x: x, y: y, chunk: len(starts) - 1})
+		x += extents.Width
+		if trailing && text != "" {
+			x += space
+		}
+		ends[len(ends)-1] = x
+	}
+
+	// align the chunks and write the pieces
+	anchors := make([]float64, len(starts))
+	for i, p := range layout {
+		if i == 0 || p.chunk != layout[i-1].chunk {
+			switch pieces[i].properties["text-anchor"] {
+			case "middle":
+				anchors[p.chunk] = -(ends[p.chunk] - starts[p.chunk]) / 2
+			case "end":
+				anchors[p.chunk] = -(ends[p.chunk] - starts[p.chunk])
+			}
+		}
+	}
+	for i, p := range layout {
+		if pieces[i].properties["visibility"] != "visible" {
+			continue
+		}
+		r.check(Text(pieces[i].text, p.x+anchors[p.chunk], p.y, p.style)(r.canvas))
+	}
+}
+
+// spaceWidth returns the width of a space in the given style.
+func spaceWidth(style TextStyle) (float64, error) {
+	spaced, err := MeasureText("x x", style)
+	if err != nil {
+		return 0, err
+	}
+	unspaced, err := MeasureText("xx", style)
+	if err != nil {
+		return 0, err
+	}
+	return spaced.Width - unspaced.Width, nil
+}
+
+// textStyle returns the style of a piece of text with the given properties;
+// gradients are approximated by their first colour.
+func (r *svgRenderer) textStyle(properties svgProperties) (TextStyle, error) {
+	f, err := r.font(properties)
+	if err != nil {
+		return TextStyle{}, err
+	}
+	style := TextStyle{
+		Fonts: []*Font{f},
+		Size:  r.number(properties["font-size"], 16),
+	}
+	if spacing := properties["letter-spacing"]; spacing != "normal" {
+		style.LetterSpacing = r.diagonal(spacing, properties)
+	}
+	paint := func(value string, opacity float64) color.Color {
+		if strings.HasPrefix(value, "url(") {
+			reference, fallback, _ := strings.Cut(strings.TrimPrefix(value, "url("), ")")
+			if g, _, ok := r.gradient(strings.TrimPrefix(strings.Trim(strings.TrimSpace(reference), `"'`), "#"), opacity, gg.NewPath(), properties); ok && len(g.Stops) > 0 {
+				return g.Stops[0].Colour
+			}
+			value = fallback
+		}
+		colour, ok := r.colour(value, properties)
+		if !ok {
+			return nil
+		}
+		rgba := gg.FromColor(colour)
+		rgba.A *= min(max(opacity, 0), 1)
+		return rgba
+	}
+	style.Colour = paint(properties["fill"], r.number(properties["fill-opacity"], 1))
+	if style.Colour == nil {
+		style.Colour = color.Transparent
+	}
+	if outline := paint(properties["stroke"], r.number(properties["stroke-opacity"], 1)); outline != nil {
+		style.OutlineColour = outline
+		style.OutlineWidth = r.diagonal(properties["stroke-width"], properties)
+	}
+	return style, nil
+}
+
+// svgGeneric are the generic font families, which are rendered with the
+// built-in font.
+var svgGeneric = []string{"serif", "sans-serif", "monospace", "cursive", "fantasy", "system-ui"}
+
+// font returns the font with the family, weight and style given by the
+// properties of a piece of text; the first family in the list that is
+// available is used, and the built-in font if there is none.
+func (r *svgRenderer) font(properties svgProperties) (*Font, error) {
+	weight := "regular"
+	switch w := properties["font-weight"]; w {
+	case "bold", "bolder":
+		weight = "bold"
+	case "lighter":
+		weight = "light"
+	default:
+		if n, err := strconv.Atoi(w); err == nil {
+			for _, candidate := range weights {
+				if float64(n) >= float64(candidate.weight)-50 {
+					weight = candidate.name
+				}
+			}
+		}
+	}
+	style := weight
+	if s := properties["font-style"]; s == "italic" || s == "oblique" {
+		style += " italic"
+	}
+	key := properties["font-family"] + ":" + style
+	if f, ok := r.fonts[key]; ok {
+		return f, nil
+	}
+
+	var f *Font
+	for _, family := range strings.Split(properties["font-family"], ",") {
+		family = strings.Trim(strings.TrimSpace(family), `"'`)
+		if family == "" {
+			continue
+		}
+		if contains(svgGeneric, family) {
+			break
+		}
+		if found, err := FindFont(family + ":" + style); err == nil {
+			f = found
+			break
+		}
+		slog.Debug("SVG font family not found", "family", family, "style", style)
+	}
+	if f == nil {
+		var err error
+		if f, err = FindFont("Go:" + style); err != nil {
+			return nil, err
+		}
+	}
+	r.fonts[key] = f
+	return f, nil
+}
+
+// contains returns whether the list contains the given string, ignoring
+// case.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// exifTimeLayout is the format of dates in EXIF metadata.
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// TemplateData is the data text templates are expanded with.
+type TemplateData struct {
+	// Path is the name of the input file, as given; it is empty if the image
+	// was read from STDIN or created from scratch.
+	Path string
+	// File is the base name of the input file.
+	File string
+	// Width and Height are the size of the image, in pixels.
+	Width, Height int
+	// EXIF holds the EXIF fields of the input image, by name.
+	EXIF map[string]string
+}
+
+// ExpandTemplate expands the given text as a Go text/template with the given
+// data, which is available as .Path, .File, .Width, .Height and .EXIF; the
+// following functions are also available:
+//   - now returns the current time, e.g. {{ now.Format "2006-01-02" }};
+//   - exif returns an EXIF field by name, e.g. {{ exif "Model" }}, or the
+//     default given as its second argument if the field is missing;
+//   - exifTime returns an EXIF date field as a time, e.g.
+//     {{ (exifTime "DateTimeOriginal").Format "2 Jan 2006" }};
+//   - env returns an environment variable, e.g. {{ env "USER" }}, or the
+//     default given as its second argument if the variable is not set.
+//
+// Missing fields and variables without a default are reported as errors.
+func ExpandTemplate(text string, data TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	lookup := func(kind, name string, value string, found bool, defaults []string) (string, error) {
+		switch {
+		case found:
+			return value, nil
+		case len(defaults) > 0:
+			return defaults[0], nil
+		}
+		return "", fmt.Errorf("%s %q not found", kind, name)
+	}
+	functions := template.FuncMap{
+		"now": time.Now,
+		"exif": func(name string, defaults ...string) (string, error) {
+			value, found := data.EXIF[name]
+			return lookup("EXIF field", name, value, found, defaults)
+		},
+		"exifTime": func(name string) (time.Time, error) {
+			value, found := data.EXIF[name]
+			if !found {
+				return time.Time{}, fmt.Errorf("EXIF field %q not found", name)
+			}
+			return time.ParseInLocation(exifTimeLayout, value, time.Local)
+		},
+		"env": func(name string, defaults ...string) (string, error) {
+			value, found := os.LookupEnv(name)
+			return lookup("environment variable", name, value, found, defaults)
+		},
+	}
+	t, err := template.New("text").Funcs(functions).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid text template: %w", err)
+	}
+	var result strings.Builder
+	if err := t.Execute(&result, data); err != nil {
+		return "", fmt.Errorf("error expanding text template: %w", err)
+	}
+	return result.String(), nil
+}
@@ -0,0 +1,782 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/anthonynsimon/bild/blur"
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
+	"github.com/gogpu/gg"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// ErrNoFont is returned when text is painted without a font.
+var ErrNoFont = errors.New("no font specified")
+
+// ErrTextTooLarge is returned when text does not fit into its box, even at
+// the minimum font size.
+var ErrTextTooLarge = errors.New("text does not fit into the box")
+
+// Alignment is the horizontal alignment of the lines of a block of text.
+type Alignment string
+
+const (
+	AlignLeft    Alignment = "left"
+	AlignCenter  Alignment = "center"
+	AlignRight   Alignment = "right"
+	AlignJustify Alignment = "justify"
+)
+
+// Direction is the base direction of the paragraphs of a block of text,
+// which determines the order of runs of text in different directions.
+type Direction string
+
+const (
+	// DirectionAuto takes the direction of each paragraph from its first
+	// character with a strong direction, as in the Unicode bidi algorithm.
+	DirectionAuto Direction = "auto"
+	// DirectionLTR lays paragraphs out from left to right.
+	DirectionLTR Direction = "ltr"
+	// DirectionRTL lays paragraphs out from right to left.
+	DirectionRTL Direction = "rtl"
+)
+
+// TextStyle describes how text is rendered.
+type TextStyle struct {
+	// Fonts is the chain of fonts used to render the text: each character
+	// is rendered with the first font that has a glyph for it.
+	Fonts []*Font
+	// Size is the size of the font, in points.
+	Size float64
+	// Colour is the colour of the text.
+	Colour color.Color
+	// Pattern, if not nil, fills the text instead of the colour; parts of
+	// the text coloured through markup keep their colour.
+	Pattern *Pattern
+	// Markup is whether the text contains tags changing the weight, slant,
+	// colour and size of parts of it (see parseMarkup).
+	Markup bool
+	// LetterSpacing is the extra space added after each character, in
+	// pixels; it can be negative to tighten the text.
+	LetterSpacing float64
+	// NoKerning disables the kerning of pairs of characters.
+	NoKerning bool
+	// Features are the OpenType features enabled or disabled when shaping
+	// the text, e.g. small capitals or tabular numerals.
+	Features []Feature
+	// Variations are the values of the axes of variable fonts, e.g. their
+	// weight; they are ignored by fonts that are not variable.
+	Variations []Variation
+	// Direction is the base direction of the paragraphs; if empty, it is
+	// detected from their contents.
+	Direction Direction
+	// Width is the width of the text box: longer lines are wrapped at word
+	// boundaries; if zero, lines are only broken at newlines.
+	Width float64
+	// Align is the alignment of the lines within the text box; if empty,
+	// lines are aligned to the left, or to the right in right-to-left
+	// paragraphs.
+	Align Alignment
+	// LineHeight is the distance between the baselines of consecutive lines,
+	// as a multiple of the line height of the font; if zero, it is 1.
+	LineHeight float64
+	// OutlineColour is the colour of the stroke around the glyphs; if nil,
+	// or if the outline width is zero, the glyphs are not stroked.
+	OutlineColour color.Color
+	// OutlineWidth is the width of the stroke around the glyphs.
+	OutlineWidth float64
+	// ShadowColour is the colour of the shadow cast by the text; if nil, no
+	// shadow is drawn.
+	ShadowColour color.Color
+	// ShadowOffsetX and ShadowOffsetY are the distance of the shadow from
+	// the text.
+	ShadowOffsetX, ShadowOffsetY float64
+	// ShadowBlur is the radius of the Gaussian blur applied to the shadow.
+	ShadowBlur float64
+	// BackgroundColour is the colour of the plate behind the text box; if
+	// nil, no plate is drawn.
+	BackgroundColour color.Color
+	// BackgroundPadding is the space between the text and the edges of the
+	// plate.
+	BackgroundPadding float64
+	// BackgroundRadius is the radius of the corners of the plate.
+	BackgroundRadius float64
+}
+
+// names returns the names of the given fonts, for logging.
+func names(fonts []*Font) []string {
+	result := make([]string, len(fonts))
+	for i, f := range fonts {
+		result[i] = f.Name
+	}
+	return result
+}
+
+// fontmap selects, for each character, the first font of a chain that has a
+// glyph for it, or the first font of the chain if none has.
+type fontmap []*font.Face
+
+// ResolveFace returns the face used to render the given character.
+func (m fontmap) ResolveFace(r rune) *font.Face {
+	for _, face := range m {
+		if _, ok := face.NominalGlyph(r); ok {
+			return face
+		}
+	}
+	return m[0]
+}
+
+// em is the size text is shaped at before being scaled to the size of the
+// font, since the shaper only works with whole sizes.
+const em = 1000
+
+// Extents are the measures of a block of text, in pixels.
+type Extents struct {
+	// Width is the width of the text box.
+	Width float64
+	// Ascent is the distance from the baseline of the first line to the top
+	// of the text.
+	Ascent float64
+	// Descent is the distance from the baseline of the first line to the
+	// bottom of the text.
+	Descent float64
+	// Lines is the number of lines the text is broken into.
+	Lines int
+}
+
+// Height returns the height of the text, from top to bottom.
+func (e Extents) Height() float64 {
+	return e.Ascent + e.Descent
+}
+
+// glyph is a shaped glyph, ready to be rendered.
+type glyph struct {
+	face *font.Face
+	id   font.GID
+	// size is the size of the font the glyph is rendered with.
+	size float64
+	// colour is the colour of the glyph; if nil, that of the style is used.
+	colour color.Color
+	// x and y are the position of the glyph relative to the origin of its
+	// line or run, in pixels.
+	x, y float64
+	// advance is the distance to the position of the next glyph.
+	advance float64
+	// cluster is the index of the first character the glyph was shaped from;
+	// glyphs from the same characters (e.g. a letter and its marks) share it.
+	cluster int
+	// space is whether the glyph is a space, which is stretched when the
+	// line is justified.
+	space bool
+}
+
+// line is a line of a block of text, after shaping and wrapping; its glyphs
+// are in visual order, from left to right.
+type line struct {
+	glyphs []glyph
+	width  float64
+	// y is the position of the baseline relative to that of the first line.
+	y float64
+	// ascent, descent and gap are the largest extents above and below the
+	// baseline and the largest line gap of the fonts in the line.
+	ascent, descent, gap float64
+	// rtl is whether the line belongs to a right-to-left paragraph.
+	rtl bool
+	// last is whether the line is the last of its paragraph.
+	last bool
+}
+
+// block is a block of text broken into lines.
+type block struct {
+	lines []line
+	// width is the width of the text box.
+	width float64
+	// widest is the width of the longest line, which may exceed that of the
+	// text box if a single word does not fit.
+	widest float64
+}
+
+// format is the resolved formatting of a span of text: the chain of fonts,
+// the size and the colour it is rendered with, along with the vertical
+// metrics of its primary font.
+type format struct {
+	chain  fontmap
+	size   float64
+	colour color.Color
+	// ascent, descent and gap are the extents of the primary font above and
+	// below the baseline, and the gap between lines.
+	ascent, descent, gap float64
+}
+
+// formats resolves the given spans against the style, replacing the fonts
+// with their bold and italic variants as needed and applying the axis values
+// of variable fonts.
+func (style TextStyle) formats(spans []span) []format {
+	variations := style.variations()
+	formats := make([]format, len(spans))
+	for i, sp := range spans {
+		f := format{chain: make(fontmap, len(style.Fonts)), size: style.Size, colour: sp.colour}
+		for j, font := range style.Fonts {
+			f.chain[j] = font.variant(sp.bold, sp.italic).face
+			f.chain[j].SetVariations(variations)
+		}
+		if sp.size > 0 {
+			f.size = sp.size
+		}
+		primary := f.chain[0]
+		metrics, ok := primary.FontHExtents()
+		if !ok {
+			metrics = font.FontExtents{Ascender: 0.8 * float32(primary.Upem()), Descender: -0.2 * float32(primary.Upem())}
+		}
+		units := f.size / float64(primary.Upem())
+		f.ascent = float64(metrics.Ascender) * units
+		f.descent = -float64(metrics.Descender) * units
+		f.gap = float64(metrics.LineGap) * units
+		formats[i] = f
+	}
+	return formats
+}
+
+// layout shapes the given text and breaks it into lines, at newlines and,
+// if the style has a width, at the word boundaries needed to fit the text box;
+// if the style allows markup, the tags in the text are applied to it.
+func (style TextStyle) layout(s string) (block, error) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	text, spans, indices := []rune(s), []span{{}}, []int(nil)
+	if style.Markup {
+		var err error
+		if text, spans, indices, err = parseMarkup(s); err != nil {
+			return block{}, err
+		}
+	} else {
+		indices = make([]int, len(text))
+	}
+	formats := style.formats(spans)
+	features := style.features()
+	b := block{width: style.Width}
+
+	// text is shaped at a size proportional to that of each span, so that
+	// all runs are measured in the same units when wrapped
+	scale := style.Size / em
+	width := fixed.Int26_6(math.MaxInt32)
+	if style.Width > 0 {
+		width = fixed.Int26_6(style.Width / scale * 64)
+	}
+	spacing := fixed.Int26_6(style.LetterSpacing / scale * 64)
+	var (
+		shaper  shaping.HarfbuzzShaper
+		wrapper shaping.LineWrapper
+	)
+	start := 0
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && text[i] != '\n' {
+			continue
+		}
+		end := i
+		for end > start && text[end-1] == ' ' {
+			end--
+		}
+		paragraph, styles := text[start:end], indices[start:end]
+		base := style.Direction.level(paragraph)
+		if len(paragraph) == 0 {
+			// empty lines take the format of their newline, if any
+			f := formats[0]
+			if len(indices) > 0 {
+				f = formats[indices[min(i, len(indices)-1)]]
+			}
+			b.lines = append(b.lines, line{ascent: f.ascent, descent: f.descent, gap: f.gap, rtl: base == 1, last: true})
+			start = i + 1
+			continue
+		}
+		// split the paragraph into runs with the same direction, script,
+		// format and font, then shape each of them
+		levels := levels(paragraph, base)
+		ends, scripts := itemize(paragraph, levels, styles)
+		var runs []shaping.Output
+		from := 0
+		for j, to := range ends {
+			f := formats[styles[from]]
+			input := shaping.Input{
+				Text:         paragraph,
+				RunStart:     from,
+				RunEnd:       to,
+				Direction:    direction(levels[from]),
+				Face:         f.chain[0],
+				Size:         fixed.I(max(1, int(math.Round(em*f.size/style.Size)))),
+				Script:       scripts[j],
+				FontFeatures: features,
+			}
+			for _, item := range shaping.SplitByFace(input, f.chain) {
+				runs = append(runs, shaper.Shape(item))
+			}
+			from = to
+		}
+		// letter spacing is added between clusters before wrapping, so that
+		// lines are measured with it
+		shaping.AddSpacing(runs, paragraph, 0, spacing)
+		config := shaping.WrapConfig{Direction: direction(base), BreakPolicy: shaping.Never}
+		wrapped, _ := wrapper.WrapParagraphF(config, width, paragraph, shaping.NewSliceIterator(runs))
+		for j, runs := range wrapped {
+			l := newLine(reorder(runs, levels), paragraph, styles, formats, scale)
+			l.rtl = base == 1
+			l.last = j == len(wrapped)-1
+			b.lines = append(b.lines, l)
+			b.widest = max(b.widest, l.width)
+		}
+		start = i + 1
+	}
+
+	// each baseline is placed below the previous one, by the extents of the
+	// lines and the line gap
+	lineHeight := style.LineHeight
+	if lineHeight <= 0 {
+		lineHeight = 1
+	}
+	for i := 1; i < len(b.lines); i++ {
+		previous := b.lines[i-1]
+		b.lines[i].y = previous.y + (previous.descent+previous.gap+b.lines[i].ascent)*lineHeight
+	}
+	if style.Width <= 0 {
+		b.width = b.widest
+	}
+	return b, nil
+}
+
+// level returns the base embedding level of a paragraph with the given text,
+// i.e. 0 if it is left-to-right and 1 if it is right-to-left.
+func (d Direction) level(text []rune) uint8 {
+	switch d {
+	case DirectionLTR:
+		return 0
+	case DirectionRTL:
+		return 1
+	}
+	for _, r := range text {
+		properties, _ := bidi.LookupRune(r)
+		switch properties.Class() {
+		case bidi.L:
+			return 0
+		case bidi.R, bidi.AL:
+			return 1
+		}
+	}
+	return 0
+}
+
+// direction returns the direction of text at the given embedding level.
+func direction(level uint8) di.Direction {
+	if level%2 == 1 {
+		return di.DirectionRTL
+	}
+	return di.DirectionLTR
+}
+
+// newLine converts a line of shaped runs, in visual order, into a line of
+// glyphs, scaled from the size the text was shaped at; the format of each
+// glyph is that of the character it was shaped from.
+func newLine(runs shaping.Line, text []rune, styles []int, formats []format, scale float64) line {
+	l := line{}
+	for _, r := range runs {
+		for _, g := range r.Glyphs {
+			f := formats[styles[min(g.ClusterIndex, len(styles)-1)]]
+			l.glyphs = append(l.glyphs, glyph{
+				face:    r.Face,
+				id:      g.GlyphID,
+				size:    f.size,
+				colour:  f.colour,
+				x:       l.width + float64(g.XOffset)/64*scale,
+				y:       -float64(g.YOffset) / 64 * scale,
+				advance: float64(g.Advance) / 64 * scale,
+				cluster: g.ClusterIndex,
+				space:   g.ClusterIndex < len(text) && unicode.IsSpace(text[g.ClusterIndex]),
+			})
+			l.width += float64(g.Advance) / 64 * scale
+			l.ascent = max(l.ascent, f.ascent)
+			l.descent = max(l.descent, f.descent)
+			l.gap = max(l.gap, f.gap)
+		}
+	}
+	return l
+}
+
+// extents returns the extents of the block of text.
+func (b block) extents() Extents {
+	last := b.lines[len(b.lines)-1]
+	return Extents{
+		Width:   b.width,
+		Ascent:  b.lines[0].ascent,
+		Descent: last.y + last.descent,
+		Lines:   len(b.lines),
+	}
+}
+
+// MeasureText returns the extents of the given text, when rendered with the
+// given style.
+func MeasureText(s string, style TextStyle) (Extents, error) {
+	if len(style.Fonts) == 0 {
+		return Extents{}, ErrNoFont
+	}
+	b, err := style.layout(s)
+	if err != nil {
+		return Extents{}, err
+	}
+	return b.extents(), nil
+}
+
+// FitText returns the largest font size, between minSize and maxSize, at which the
+// given text, wrapped to the width of the box, fits into a box of the given
+// size; the font size and the width in the style are ignored.
+func FitText(s string, style TextStyle, width, height, minSize, maxSize float64) (float64, error) {
+	if len(style.Fonts) == 0 {
+		return 0, ErrNoFont
+	}
+	style.Width = width
+	// the layout can only fail on invalid markup, which does not depend on
+	// the size
+	if _, err := style.layout(s); err != nil {
+		return 0, err
+	}
+	fits := func(size float64) bool {
+		style.Size = size
+		b, _ := style.layout(s)
+		return b.widest <= width && b.extents().Height() <= height
+	}
+
+	if !fits(minSize) {
+		return 0, ErrTextTooLarge
+	}
+	if fits(maxSize) {
+		return maxSize, nil
+	}
+	// binary search for the largest size that fits, to a tenth of a point
+	for maxSize-minSize > 0.1 {
+		size := (minSize + maxSize) / 2
+		if fits(size) {
+			minSize = size
+		} else {
+			maxSize = size
+		}
+	}
+	return minSize, nil
+}
+
+// Text returns a Painter that writes the given text with the baseline of its
+// first line starting at (x, y); the text is broken into lines at newlines
+// and, if the style has a width, wrapped to fit the text box. The background
+// plate, the shadow and the outline, if any, are painted below the glyphs.
+func Text(s string, x, y float64, style TextStyle) Painter {
+	return func(c *Canvas) error {
+		if len(style.Fonts) == 0 {
+			return ErrNoFont
+		}
+		slog.Debug("drawing text", "text", s, "x", x, "y", y, "size", style.Size, "fonts", names(style.Fonts), "direction", style.Direction, "markup", style.Markup)
+		b, err := style.layout(s)
+		if err != nil {
+			return err
+		}
+
+		if style.BackgroundColour != nil {
+			extents := b.extents()
+			padding := style.BackgroundPadding
+			slog.Debug("drawing text background", "colour", style.BackgroundColour, "padding", padding, "radius", style.BackgroundRadius)
+			c.context.DrawRoundedRectangle(x-padding, y-extents.Ascent-padding, extents.Width+2*padding, extents.Height()+2*padding, style.BackgroundRadius)
+			c.context.SetColor(style.BackgroundColour)
+			if err := c.context.Fill(); err != nil {
+				return err
+			}
+		}
+
+		return b.render(c, b.runs(x, y, style.Align), style)
+	}
+}
+
+// TextOnArc returns a Painter that writes the given text along the circle
+// with the given centre and radius, from the start angle towards the end
+// angle (in degrees, clockwise from the x axis); the glyphs stand on the
+// circle, outside it if the text runs clockwise and inside it otherwise.
+// The text is aligned along the arc according to the style; newlines are
+// replaced by spaces, and the width and the background of the style are
+// ignored.
+func TextOnArc(s string, x, y, radius, from, to float64, style TextStyle) Painter {
+	return func(c *Canvas) error {
+		if len(style.Fonts) == 0 {
+			return ErrNoFont
+		}
+		if radius <= 0 {
+			return fmt.Errorf("invalid radius: %g", radius)
+		}
+		slog.Debug("drawing text on arc", "text", s, "x", x, "y", y, "radius", radius, "from", from, "to", to, "size", style.Size, "fonts", names(style.Fonts))
+		style.Width = 0
+		b, err := style.layout(strings.Join(strings.Fields(s), " "))
+		if err != nil {
+			return err
+		}
+
+		// group the glyphs into clusters, each placed along the arc as a
+		// whole so that marks stay on their letters
+		var clusters [][]glyph
+		for i, g := range b.lines[0].glyphs {
+			if i == 0 || g.cluster != clusters[len(clusters)-1][0].cluster {
+				clusters = append(clusters, nil)
+			}
+			clusters[len(clusters)-1] = append(clusters[len(clusters)-1], g)
+		}
+		advances := make([]float64, len(clusters))
+		for i, cluster := range clusters {
+			for _, g := range cluster {
+				advances[i] += g.advance
+			}
+		}
+		total := b.lines[0].width
+
+		direction := 1.0
+		if to < from {
+			direction = -1
+		}
+		available := math.Abs(radians(to-from)) * radius
+		position, spacing := 0.0, 0.0
+		switch style.Align {
+		case AlignCenter:
+			position = (available - total) / 2
+		case AlignRight:
+			position = available - total
+		case AlignJustify:
+			if len(clusters) > 1 {
+				spacing = (available - total) / float64(len(clusters)-1)
+			}
+		}
+
+		runs := make([]run, 0, len(clusters))
+		start := 0.0
+		for i, cluster := range clusters {
+			// the middle of the cluster lies on the arc, and the cluster is
+			// rotated to be tangent to it
+			angle := radians(from) + direction*(position+advances[i]/2)/radius
+			rotation := angle + direction*math.Pi/2
+			cx, cy := x+radius*math.Cos(angle), y+radius*math.Sin(angle)
+			glyphs := slices.Clone(cluster)
+			for j := range glyphs {
+				glyphs[j].x -= start
+			}
+			runs = append(runs, run{
+				glyphs: glyphs,
+				x:      cx - advances[i]/2*math.Cos(rotation),
+				y:      cy - advances[i]/2*math.Sin(rotation),
+				angle:  rotation,
+			})
+			position += advances[i] + spacing
+			start += advances[i]
+		}
+
+		return b.render(c, runs, style)
+	}
+}
+
+// Rotated returns a Painter that runs the given painter with the canvas
+// rotated by the given angle, in degrees, around (x, y).
+func Rotated(angle, x, y float64, painter Painter) Painter {
+	return func(c *Canvas) error {
+		slog.Debug("rotating canvas", "angle", angle, "x", x, "y", y)
+		c.context.Push()
+		defer c.context.Pop()
+		c.context.RotateAbout(radians(angle), x, y)
+		return painter(c)
+	}
+}
+
+// render paints the shadow, if any, and the given runs of text.
+func (b block) render(c *Canvas, runs []run, style TextStyle) error {
+	if style.ShadowColour != nil {
+		slog.Debug("drawing text shadow", "colour", style.ShadowColour, "dx", style.ShadowOffsetX, "dy", style.ShadowOffsetY, "blur", style.ShadowBlur)
+		// the shadow is offset in image space, regardless of any rotation
+		shadow := NewCanvas(c.sizeX, c.sizeY)
+		defer shadow.Close()
+		shadow.context.Translate(style.ShadowOffsetX, style.ShadowOffsetY)
+		shadow.context.Transform(c.context.GetTransform())
+		if err := b.paint(shadow, runs, style.ShadowColour, nil, style.ShadowColour, style.OutlineWidth, true); err != nil {
+			return err
+		}
+		var img image.Image = shadow.Image()
+		if style.ShadowBlur > 0 {
+			img = blur.Gaussian(img, style.ShadowBlur)
+		}
+		c.context.Push()
+		c.context.Identity()
+		c.context.DrawImage(gg.ImageBufFromImage(img), 0, 0)
+		c.context.Pop()
+	}
+
+	return b.paint(c, runs, style.Colour, style.Pattern, style.OutlineColour, style.OutlineWidth, false)
+}
+
+// run is a sequence of glyphs written at a given position, i.e. a line; when
+// following a path, each cluster of glyphs is a run.
+type run struct {
+	glyphs []glyph
+	x, y   float64
+	// angle is the rotation of the run around its origin, in radians.
+	angle float64
+}
+
+// runs returns the lines of the block, positioned according to the given
+// alignment, with the baseline of the first line at (x, y).
+func (b block) runs(x, y float64, align Alignment) []run {
+	runs := make([]run, 0, len(b.lines))
+	for _, l := range b.lines {
+		r := run{glyphs: l.glyphs, x: x, y: y + l.y}
+		switch align {
+		case AlignLeft:
+		case AlignCenter:
+			r.x += (b.width - l.width) / 2
+		case AlignRight:
+			r.x += b.width - l.width
+		case AlignJustify:
+			if glyphs, ok := b.justify(l); ok {
+				r.glyphs = glyphs
+				break
+			}
+			fallthrough
+		default:
+			// lines of right-to-left paragraphs start on the right
+			if l.rtl {
+				r.x += b.width - l.width
+			}
+		}
+		runs = append(runs, r)
+	}
+	return runs
+}
+
+// justify spreads the words of a line evenly across the text box, by
+// stretching the spaces between them; the last line of a paragraph and lines
+// without spaces are not justified.
+func (b block) justify(l line) ([]glyph, bool) {
+	spaces := 0
+	for _, g := range l.glyphs {
+		if g.space {
+			spaces++
+		}
+	}
+	if l.last || spaces == 0 {
+		return nil, false
+	}
+	gap := (b.width - l.width) / float64(spaces)
+	glyphs := slices.Clone(l.glyphs)
+	shift := 0.0
+	for i := range glyphs {
+		glyphs[i].x += shift
+		if glyphs[i].space {
+			shift += gap
+		}
+	}
+	return glyphs, true
+}
+
+// paint fills the glyphs of the given runs, after stroking their outline if
+// the outline colour is not nil and the width is positive; glyphs are filled
+// with their own colour, if any, unless override is set, and with the given
+// pattern or fill colour otherwise.
+func (b block) paint(c *Canvas, runs []run, fill color.Color, pattern *Pattern, outline color.Color, width float64, override bool) error {
+	all := func(glyph) bool { return true }
+	if outline != nil && width > 0 {
+		b.path(c.context, runs, all)
+		c.context.SetColor(outline)
+		c.context.SetStroke(gg.DefaultStroke().WithWidth(width).WithJoin(gg.LineJoinRound))
+		if err := c.context.Stroke(); err != nil {
+			return err
+		}
+	}
+	if override {
+		b.path(c.context, runs, all)
+		c.context.SetColor(fill)
+		return c.context.Fill()
+	}
+	// glyphs are filled in one go per colour
+	var colours []color.Color
+	for _, r := range runs {
+		for _, g := range r.glyphs {
+			if !slices.Contains(colours, g.colour) {
+				colours = append(colours, g.colour)
+			}
+		}
+	}
+	for _, colour := range colours {
+		b.path(c.context, runs, func(g glyph) bool { return g.colour == colour })
+		switch {
+		case colour == nil && pattern != nil:
+			c.context.SetFillBrush(pattern.brush(c.context.GetTransform()))
+		case colour == nil:
+			c.context.SetColor(fill)
+		default:
+			c.context.SetColor(colour)
+		}
+		if err := c.context.Fill(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// path adds the outlines of the glyphs of the given runs for which include
+// returns true to the current path; glyphs without an outline (e.g. bitmap
+// emoji) are skipped.
+func (b block) path(dc *gg.Context, runs []run, include func(glyph) bool) {
+	for _, r := range runs {
+		dc.Push()
+		dc.RotateAbout(r.angle, r.x, r.y)
+		for _, g := range r.glyphs {
+			if include(g) {
+				b.contours(dc, r, g)
+			}
+		}
+		dc.Pop()
+	}
+}
+
+// contours adds the outline of a glyph of the given run to the current path.
+func (b block) contours(dc *gg.Context, r run, g glyph) {
+	outline, ok := g.face.GlyphData(g.id).(font.GlyphOutline)
+	if !ok {
+		return
+	}
+	// outlines are in font units, with the y axis pointing upwards
+	scale := g.size / float64(g.face.Upem())
+	point := func(p ot.SegmentPoint) (float64, float64) {
+		return r.x + g.x + float64(p.X)*scale, r.y + g.y - float64(p.Y)*scale
+	}
+	for i, segment := range outline.Segments {
+		switch segment.Op {
+		case ot.SegmentOpMoveTo:
+			// each contour is implicitly closed by the next one
+			if i > 0 {
+				dc.ClosePath()
+			}
+			dc.MoveTo(point(segment.Args[0]))
+		case ot.SegmentOpLineTo:
+			dc.LineTo(point(segment.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			dc.QuadraticTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := point(segment.Args[0])
+			x2, y2 := point(segment.Args[1])
+			x3, y3 := point(segment.Args[2])
+			dc.CubicTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	if len(outline.Segments) > 0 {
+		dc.ClosePath()
+	}
+}
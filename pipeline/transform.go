@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"image"
+
+	"github.com/anthonynsimon/bild/transform"
+)
+
+// Transform is a function that transforms an image into a new one.
+type Transform func(img image.Image) image.Image
+
+// Crop returns a Transform that crops an image to the given rectangle.
+func Crop(r image.Rectangle) Transform {
+	return func(img image.Image) image.Image {
+		return transform.Crop(img, r)
+	}
+}
+
+// Rotate returns a Transform that rotates an image by the given angle, in
+// degrees, around the given pivot (or around its centre if the pivot is nil);
+// if resize is true, the bounds of the result are enlarged to fit the whole
+// rotated image.
+func Rotate(angle float64, pivot *image.Point, resize bool) Transform {
+	return func(img image.Image) image.Image {
+		return transform.Rotate(img, angle, &transform.RotationOptions{
+			ResizeBounds: resize,
+			Pivot:        pivot,
+		})
+	}
+}
+
+// Zoom returns a Transform that zooms an image by the given factor (>1.0
+// zooms in, <1.0 zooms out) around the given pivot (or around its centre if
+// the pivot is nil).
+func Zoom(factor float64, pivot *image.Point) Transform {
+	return func(img image.Image) image.Image {
+		return transform.Zoom(img, factor, &transform.ZoomOptions{
+			Pivot: pivot,
+		})
+	}
+}
+
+// FlipHorizontally is a Transform that flips an image horizontally.
+func FlipHorizontally(img image.Image) image.Image {
+	return transform.FlipH(img)
+}
+
+// FlipVertically is a Transform that flips an image vertically.
+func FlipVertically(img image.Image) image.Image {
+	return transform.FlipV(img)
+}
+
+// Filter is a resampling filter, used when resizing images.
+type Filter string
+
+const (
+	// FilterNearest picks the nearest pixel; it is the fastest, and keeps
+	// pixel art sharp.
+	FilterNearest Filter = "nearest"
+	// FilterBox averages the pixels covered by each new one.
+	FilterBox Filter = "box"
+	// FilterLinear interpolates linearly between pixels.
+	FilterLinear Filter = "linear"
+	// FilterGaussian blends pixels with a Gaussian, giving soft results.
+	FilterGaussian Filter = "gaussian"
+	// FilterMitchell is the Mitchell-Netravali cubic filter, a balance
+	// between sharpness and smoothness.
+	FilterMitchell Filter = "mitchell"
+	// FilterCatmullRom is the Catmull-Rom cubic filter, sharper than the
+	// Mitchell-Netravali one.
+	FilterCatmullRom Filter = "catmull-rom"
+	// FilterLanczos is the Lanczos filter, the sharpest, especially when
+	// reducing images.
+	FilterLanczos Filter = "lanczos"
+)
+
+// filters maps the resampling filters to their implementations.
+var filters = map[Filter]transform.ResampleFilter{
+	FilterNearest:    transform.NearestNeighbor,
+	FilterBox:        transform.Box,
+	FilterLinear:     transform.Linear,
+	FilterGaussian:   transform.Gaussian,
+	FilterMitchell:   transform.MitchellNetravali,
+	FilterCatmullRom: transform.CatmullRom,
+	FilterLanczos:    transform.Lanczos,
+}
+
+// Resize returns a Transform that resizes an image to the given width and
+// height, in pixels, with the given resampling filter (linear if unknown).
+func Resize(width, height int, filter Filter) Transform {
+	return func(img image.Image) image.Image {
+		f, ok := filters[filter]
+		if !ok {
+			f = transform.Linear
+		}
+		return transform.Resize(img, width, height, f)
+	}
+}